@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-nostr/nostr/event/clientauthenticationevent"
+	"github.com/go-nostr/nostr/message"
+	"github.com/go-nostr/nostr/message/authmessage"
+	"github.com/go-nostr/nostr/message/okmessage"
+)
+
+// handleAuth implements NIP-42 for a message received from the relay. When a
+// Signer is configured, challenges are answered automatically and events and
+// subscriptions rejected with the "auth-required:" prefix are sent again once
+// the relay accepted the authentication. Up to QueueSize of them are kept for
+// as long as the connection lasts. It reports whether the message is an OK or
+// CLOSED message whose event or subscription will be sent again.
+func (cl *Client) handleAuth(ctx context.Context, r *Relay, env message.Envelope) bool {
+	switch env := env.(type) {
+	case *message.AuthEnvelope:
+		if env.Event != nil {
+			return false
+		}
+		r.mu.Lock()
+		r.challenge = env.Challenge
+		r.authed = false
+		r.mu.Unlock()
+		cl.authenticate(ctx, r)
+	case *message.OkEnvelope:
+		r.mu.Lock()
+		if env.EventID == r.authID && r.authID != "" {
+			r.authID = ""
+			r.authed = env.OK
+			retries := r.retries
+			r.retries = nil
+			r.mu.Unlock()
+			if !env.OK {
+				cl.report(fmt.Errorf("authentication to %s failed: %s", r.url, env.Reason))
+				return false
+			}
+			for _, msg := range retries {
+				cl.resend(ctx, r, msg)
+			}
+			return false
+		}
+		sent, ok := r.events.take(env.EventID)
+		retry := ok && !env.OK && cl.shouldRetry(r, env.Reason)
+		if retry {
+			r.retries = append(r.retries, sent)
+		}
+		r.mu.Unlock()
+		return retry
+	case *message.ClosedEnvelope:
+		r.mu.Lock()
+		sent, ok := r.subs[env.SubscriptionID]
+		delete(r.subs, env.SubscriptionID)
+		retry := ok && cl.shouldRetry(r, env.Reason)
+		if retry {
+			r.retries = append(r.retries, sent)
+		}
+		r.mu.Unlock()
+		return retry
+	}
+	return false
+}
+
+// authenticate signs and sends an authentication event answering the
+// challenge of the relay, unless no Signer is configured.
+func (cl *Client) authenticate(ctx context.Context, r *Relay) {
+	if cl.Signer == nil {
+		return
+	}
+	r.mu.Lock()
+	evt := clientauthenticationevent.New(r.url, r.challenge)
+	r.mu.Unlock()
+	if err := evt.SignWith(ctx, cl.Signer); err != nil {
+		cl.report(fmt.Errorf("could not sign authentication to %s: %w", r.url, err))
+		return
+	}
+	r.mu.Lock()
+	r.authID = evt.ID
+	r.mu.Unlock()
+	cl.resend(ctx, r, authmessage.New("", evt))
+}
+
+// resend writes the message to the relay again.
+func (cl *Client) resend(ctx context.Context, r *Relay, msg message.Message) {
+	data, err := msg.Marshal()
+	if err != nil {
+		cl.report(err)
+		return
+	}
+	if err := r.send(ctx, msg, data); err != nil {
+		cl.report(err)
+	}
+}
+
+// shouldRetry reports whether a message rejected for the reason should be sent
+// again after authenticating, which it is not once QueueSize messages wait to
+// be. The relay must be locked.
+func (cl *Client) shouldRetry(r *Relay, reason string) bool {
+	prefix, _ := okmessage.ParseReason(reason)
+	return prefix == okmessage.PrefixAuthRequired && cl.Signer != nil && !r.authed &&
+		len(r.retries) < r.queueSize
+}
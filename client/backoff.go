@@ -0,0 +1,50 @@
+package client
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// DefaultBackoff is the Backoff used when the options do not set one.
+var DefaultBackoff = &Backoff{
+	Min:    time.Second,
+	Max:    time.Minute,
+	Factor: 2,
+	Jitter: 0.5,
+}
+
+// Backoff configures the exponential delays between reconnection attempts to
+// a relay.
+type Backoff struct {
+	Min    time.Duration // Min is the delay before the first attempt.
+	Max    time.Duration // Max caps the delay between attempts.
+	Factor float64       // Factor multiplies the delay after each failed attempt.
+	Jitter float64       // Jitter is the fraction of the delay, from 0 to 1, that is randomized.
+}
+
+// maxDelay is the longest delay a time.Duration can hold.
+const maxDelay = time.Duration(math.MaxInt64)
+
+// Delay returns the delay before the given attempt, starting at 0. The delay
+// grows by Factor from Min up to Max, or up to the longest time.Duration when
+// Max is not set, and up to Jitter of it is randomly removed so that clients
+// do not reconnect in lockstep.
+func (b *Backoff) Delay(attempt int) time.Duration {
+	if b.Min <= 0 {
+		return 0
+	}
+	d := float64(b.Min) * math.Pow(math.Max(b.Factor, 1), float64(attempt))
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	// math.Pow overflows to +Inf after enough attempts.
+	d = math.Min(d, float64(maxDelay))
+	if j := math.Min(math.Max(b.Jitter, 0), 1); j > 0 {
+		d -= d * j * rand.Float64()
+	}
+	if d >= float64(maxDelay) {
+		return maxDelay
+	}
+	return time.Duration(d)
+}
@@ -2,17 +2,21 @@ package client
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/go-nostr/nostr/event"
 	"github.com/go-nostr/nostr/message"
 	"nhooyr.io/websocket"
 )
 
 // New creates a new Client with the given options.
 // It sets up channels for errors and messages, default handlers for errors and messages,
-// and a map for active WebSocket connections.
+// and the pool of relays.
 func New(opt *Options) *Client {
 	if opt == nil {
 		opt = &Options{}
@@ -20,58 +24,136 @@ func New(opt *Options) *Client {
 	if opt.ReadLimit == 0 {
 		opt.ReadLimit = 2e6
 	}
+	if opt.Backoff == nil {
+		opt.Backoff = DefaultBackoff
+	}
+	if opt.PingInterval == 0 {
+		opt.PingInterval = 30 * time.Second
+	}
+	if opt.PublishTimeout == 0 {
+		opt.PublishTimeout = 10 * time.Second
+	}
+	if opt.BufferSize <= 0 {
+		opt.BufferSize = 100
+	}
+	if opt.QueueSize <= 0 {
+		opt.QueueSize = 100
+	}
+	var events *seen
+	if opt.DedupeSize > 0 {
+		events = newSeen(opt.DedupeSize)
+	}
 	return &Client{
 		Options: opt,
 
-		errCh: make(chan error),
+		acks:  make(map[ack]chan *message.OkEnvelope),
+		errCh: make(chan error, opt.BufferSize),
 		errFn: func(err error) {
-			fmt.Printf("No error handler registered")
+			fmt.Println("No error handler registered.")
 		},
-		msgCh: make(chan message.Message),
+		events: events,
+		msgCh:  make(chan relayMessage, opt.BufferSize),
 		msgFn: func(msg message.Message) {
-			fmt.Printf("No message handler registered.")
+			fmt.Println("No message handler registered.")
 		},
-		connMap: make(map[*websocket.Conn]struct{}),
+		relays: make(map[string]*Relay),
+		subs:   make(map[string]*Subscription),
 	}
 }
 
 // Options represents the configuration options for a Client.
-// It currently only includes a read limit for WebSocket connections.
+// It includes a read limit for WebSocket connections, the Signer used to
+// answer NIP-42 authentication challenges of relays, the Backoff between
+// attempts to reconnect to relays that dropped the connection, the interval
+// of the pings detecting half-open connections, and how long Publish waits for
+// the OK messages of relays. Received messages and errors are buffered up to
+// BufferSize until Listen passes them to the handlers, and Overflow is the
+// policy applied to messages when the buffer is full. Errors are dropped
+// oldest first. A negative PingInterval disables pings. When DedupeSize is
+// positive, events received from several relays for the same subscription are
+// delivered once, and the relays the last DedupeSize events were seen on are
+// remembered. Up to QueueSize events sent to a relay waiting to reconnect are
+// queued, and later ones are dropped.
 type Options struct {
-	ReadLimit int64
+	ReadLimit        int64
+	Signer           event.Signer
+	Backoff          *Backoff
+	DisableReconnect bool
+	PingInterval     time.Duration
+	PublishTimeout   time.Duration
+	BufferSize       int
+	Overflow         Overflow
+	DedupeSize       int
+	QueueSize        int
 }
 
 // Client is a structure representing a client in a WebSocket communication setup.
 // It contains options for configuration, channels for errors and messages,
-// handlers for errors and messages, a map for managing active connections,
-// and a Mutex for safe concurrent access.
+// handlers for errors and messages, the pool of relays keyed by normalized URL,
+// the subscriptions keyed by ID, and a Mutex for safe concurrent access.
 type Client struct {
 	*Options
 
-	connMap map[*websocket.Conn]struct{}
-	errCh   chan error
-	errFn   func(err error)
-	msgCh   chan message.Message
-	msgFn   func(msg message.Message)
-	mu      sync.Mutex
+	acks       map[ack]chan *message.OkEnvelope
+	errCh      chan error
+	errFn      func(err error)
+	events     *seen
+	msgCh      chan relayMessage
+	msgFn      func(msg message.Message)
+	relayMsgFn func(r *Relay, msg message.Message)
+	relays     map[string]*Relay
+	subs       map[string]*Subscription
+	mu         sync.Mutex
 }
 
-// Connect establishes a WebSocket connection to the given URL.
-// It adds the new connection to the client's map of connections and starts listening on it.
-func (cl *Client) Connect(ctx context.Context, u string) {
-	conn, _, err := websocket.Dial(ctx, u, &websocket.DialOptions{
-		CompressionMode: websocket.CompressionDisabled,
-	})
+// relayMessage is a message received from a relay.
+type relayMessage struct {
+	msg   message.Message
+	relay *Relay
+}
+
+// Connect establishes a WebSocket connection to the relay at the given URL.
+// It adds the relay to the client's pool, keyed by its normalized URL, and
+// starts listening on the connection. Connecting to a relay that is not closed
+// does nothing. When the connection drops, the client reconnects until the
+// context is done or the relay is disconnected.
+func (cl *Client) Connect(ctx context.Context, u string) error {
+	key, err := NormalizeURL(u)
 	if err != nil {
-		cl.errCh <- err
+		return err
+	}
+	cl.mu.Lock()
+	r, ok := cl.relays[key]
+	if !ok {
+		r = newRelay(key, cl.QueueSize)
+		cl.relays[key] = r
+	}
+	cl.mu.Unlock()
+	if ok && !r.connect() {
+		return nil
 	}
-	if cl.Options != nil {
-		conn.SetReadLimit(cl.ReadLimit)
+	if err := cl.dial(ctx, r); err != nil {
+		r.close()
+		return err
+	}
+	return nil
+}
+
+// Disconnect closes the connection to the relay at the given URL and removes
+// it from the client's pool.
+func (cl *Client) Disconnect(u string) error {
+	key, err := NormalizeURL(u)
+	if err != nil {
+		return err
 	}
 	cl.mu.Lock()
-	defer cl.mu.Unlock()
-	cl.connMap[conn] = struct{}{}
-	go cl.listenConnection(ctx, conn)
+	r, ok := cl.relays[key]
+	delete(cl.relays, key)
+	cl.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotConnected, key)
+	}
+	return r.close()
 }
 
 // HandleErrorFunc sets the function to be called when an error occurs.
@@ -86,74 +168,303 @@ func (cl *Client) HandleMessageFunc(fn func(message.Message)) {
 	cl.msgFn = fn
 }
 
+// HandleRelayMessageFunc sets the function to be called with the relay that
+// sent each received message. It is called in addition to the message
+// handler.
+func (cl *Client) HandleRelayMessageFunc(fn func(r *Relay, msg message.Message)) {
+	cl.relayMsgFn = fn
+}
+
 // Listen starts listening for errors and messages on the client's channels.
-// When a message or an error is received, it calls the appropriate handler function in a new goroutine.
+// When a message or an error is received, it calls the appropriate handler function and waits for it to return,
+// so that messages are handled in order and a slow handler lets the buffer fill up and the Overflow policy apply.
 // The function returns when the provided context is done.
 func (cl *Client) Listen(ctx context.Context) error {
 	for {
 		select {
 		case err := <-cl.errCh:
-			go cl.errFn(err)
-		case msg := <-cl.msgCh:
-			go cl.msgFn(msg)
+			cl.errFn(err)
+		case rm := <-cl.msgCh:
+			cl.msgFn(rm.msg)
+			if fn := cl.relayMsgFn; fn != nil {
+				fn(rm.relay, rm.msg)
+			}
 		case <-ctx.Done():
 			return nil
 		}
 	}
 }
 
-// SendMessage sends the given message to all active WebSocket connections of the client.
-// It first marshals the message into a byte slice. If an error occurs, it sends the error on the error channel.
-func (cl *Client) SendMessage(ctx context.Context, msg message.Message) {
-	data, err := msg.Marshal()
+// Relay returns the relay of the pool at the given URL, or nil if the client
+// never connected to it.
+func (cl *Client) Relay(u string) *Relay {
+	key, err := NormalizeURL(u)
 	if err != nil {
-		cl.errCh <- err
+		return nil
 	}
 	cl.mu.Lock()
 	defer cl.mu.Unlock()
-	for conn := range cl.connMap {
-		if err := conn.Write(ctx, websocket.MessageText, data); err != nil {
-			cl.errCh <- err
+	return cl.relays[key]
+}
+
+// Relays returns the relays of the pool, sorted by URL.
+func (cl *Client) Relays() []*Relay {
+	cl.mu.Lock()
+	relays := make([]*Relay, 0, len(cl.relays))
+	for _, r := range cl.relays {
+		relays = append(relays, r)
+	}
+	cl.mu.Unlock()
+	sort.Slice(relays, func(i, j int) bool {
+		return relays[i].url < relays[j].url
+	})
+	return relays
+}
+
+// SendMessage sends the given message to all relays of the client that are not closed.
+// It returns the errors of the relays the message could not be sent to.
+func (cl *Client) SendMessage(ctx context.Context, msg message.Message) error {
+	return cl.sendMessage(ctx, msg, cl.Relays())
+}
+
+// SendMessageTo sends the given message to the relays at the given URLs,
+// which must be in the client's pool. It returns the errors of the relays the
+// message could not be sent to.
+func (cl *Client) SendMessageTo(ctx context.Context, msg message.Message, urls ...string) error {
+	var errs []error
+	relays := make([]*Relay, 0, len(urls))
+	for _, u := range urls {
+		r := cl.Relay(u)
+		if r == nil {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrNotConnected, u))
+			continue
+		}
+		relays = append(relays, r)
+	}
+	if err := cl.sendMessage(ctx, msg, relays); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// sendMessage sends the message to the relays among the given ones that are
+// not closed.
+func (cl *Client) sendMessage(ctx context.Context, msg message.Message, relays []*Relay) error {
+	data, err := msg.Marshal()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, r := range relays {
+		if r.Status() == StatusClosed {
+			continue
+		}
+		if err := r.send(ctx, msg, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// dial connects to the relay and starts listening on the connection, then
+// resumes the subscriptions and sends the queued events of the relay.
+func (cl *Client) dial(ctx context.Context, r *Relay) error {
+	ws, _, err := websocket.Dial(ctx, r.url, &websocket.DialOptions{
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		return fmt.Errorf("could not connect to %s: %w", r.url, err)
+	}
+	ws.SetReadLimit(cl.ReadLimit)
+	subs, queue, ok := r.connected(ws)
+	if !ok {
+		return ws.Close(websocket.StatusNormalClosure, "closing connection")
+	}
+	go cl.listenConnection(ctx, r, ws)
+	go cl.keepAlive(ctx, r, ws)
+	for _, msg := range subs {
+		data, err := msg.Marshal()
+		if err != nil {
+			cl.report(err)
+			continue
+		}
+		if err := ws.Write(ctx, websocket.MessageText, data); err != nil {
+			cl.report(err)
 		}
 	}
+	for _, msg := range queue {
+		if err := r.Send(ctx, msg); err != nil {
+			cl.report(err)
+		}
+	}
+	return nil
+}
+
+// reconnect dials the relay again, waiting for the delays of the Backoff
+// between attempts, until it is connected, closed, or the context is done.
+func (cl *Client) reconnect(ctx context.Context, r *Relay) {
+	r.mu.Lock()
+	done := r.done
+	r.mu.Unlock()
+	for attempt := 0; ; attempt++ {
+		timer := time.NewTimer(cl.Backoff.Delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			r.close()
+			return
+		case <-done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if !r.transition(StatusBackoff, StatusConnecting) {
+			return
+		}
+		err := cl.dial(ctx, r)
+		if err == nil {
+			return
+		}
+		if !r.transition(StatusConnecting, StatusBackoff) {
+			return
+		}
+		cl.report(err)
+	}
 }
 
-// listenConnection starts listening for messages on a WebSocket connection.
-// When a text message is received, it decodes the message and sends it on the message channel.
-// If an error occurs or a non-text message is received, it sends the error on the error channel and returns.
-func (cl *Client) listenConnection(ctx context.Context, conn *websocket.Conn) {
-	defer cl.removeConnection(conn)
+// keepAlive pings the relay every PingInterval and closes the connection when
+// the relay does not answer in time, so that half-open connections are
+// detected and reconnected.
+func (cl *Client) keepAlive(ctx context.Context, r *Relay, ws *websocket.Conn) {
+	if cl.PingInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cl.PingInterval)
+	defer ticker.Stop()
 	for {
-		typ, rdr, err := conn.Reader(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !r.isConn(ws) {
+			return
+		}
+		pingCtx, cancel := context.WithTimeout(ctx, cl.PingInterval)
+		err := ws.Ping(pingCtx)
+		cancel()
 		if err != nil {
-			cl.errCh <- err
+			if r.isConn(ws) {
+				ws.Close(websocket.StatusGoingAway, "ping timeout")
+			}
+			return
+		}
+	}
+}
+
+// listenConnection starts listening for messages on the WebSocket connection to a relay.
+// When a text message is received, it decodes the message, handles NIP-42 authentication and routes it to its
+// subscription, or dispatches it on the message channel. If an error occurs or a non-text message is received,
+// it reports the error and returns, reconnecting to the relay unless reconnection is disabled. Errors caused by
+// disconnecting the relay are not reported.
+func (cl *Client) listenConnection(ctx context.Context, r *Relay, ws *websocket.Conn) {
+	lost := func(status websocket.StatusCode, err error) {
+		reconnect := !cl.DisableReconnect && ctx.Err() == nil
+		if !r.disconnected(ws, reconnect) {
+			return
+		}
+		ws.Close(status, "closing connection")
+		cl.report(err)
+		if reconnect {
+			go cl.reconnect(ctx, r)
+		}
+	}
+	for {
+		typ, rdr, err := ws.Reader(ctx)
+		if err != nil {
+			lost(websocket.StatusNormalClosure, fmt.Errorf("connection to %s lost: %w", r.url, err))
 			return
 		}
 		if typ != websocket.MessageText {
-			cl.errCh <- err
+			lost(websocket.StatusUnsupportedData, fmt.Errorf("unexpected binary message from %s", r.url))
+			return
+		}
+		data, err := io.ReadAll(rdr)
+		if err != nil {
+			lost(websocket.StatusNormalClosure, fmt.Errorf("connection to %s lost: %w", r.url, err))
 			return
 		}
 		var msg message.Message
-		if err := json.NewDecoder(rdr).Decode(&msg); err != nil {
-			cl.errCh <- err
+		if err := msg.Unmarshal(data); err != nil {
+			cl.report(err)
+			continue
+		}
+		if env, err := message.ParseMessage(msg); err == nil {
+			r.received(env)
+			if !cl.firstSeen(r, env) {
+				continue
+			}
+			retry := cl.handleAuth(ctx, r, env)
+			if cl.route(r, env, retry) {
+				continue
+			}
+		}
+		if !cl.dispatch(ctx, r, ws, relayMessage{msg: msg, relay: r}) {
 			return
 		}
+	}
+}
+
+// dispatch sends the message on the message channel, applying the Overflow
+// policy when the channel is full. It reports whether listening on the
+// connection should go on.
+func (cl *Client) dispatch(ctx context.Context, r *Relay, ws *websocket.Conn, rm relayMessage) bool {
+	switch cl.Overflow {
+	case OverflowBlock:
 		select {
 		case <-ctx.Done():
-			return
-		default:
-			cl.msgCh <- msg
+			r.disconnected(ws, false)
+			ws.Close(websocket.StatusNormalClosure, "closing connection")
+			return false
+		case cl.msgCh <- rm:
+		}
+	case OverflowDisconnect:
+		if !tryPush(cl.msgCh, rm) {
+			if r.disconnected(ws, false) {
+				ws.Close(websocket.StatusPolicyViolation, "message buffer is full")
+				cl.report(fmt.Errorf("%w: disconnected from %s", ErrOverflow, r.url))
+			}
+			return false
 		}
+	default:
+		dropOldest(cl.msgCh, rm)
 	}
+	return true
 }
 
-// removeConnection removes a WebSocket connection from the client's map of connections and closes it.
-// If an error occurs while closing the connection, it sends the error on the error channel.
-func (cl *Client) removeConnection(conn *websocket.Conn) {
-	cl.mu.Lock()
-	defer cl.mu.Unlock()
-	delete(cl.connMap, conn)
-	if err := conn.Close(websocket.StatusNormalClosure, "closing connection"); err != nil {
-		cl.errCh <- err
+// SeenOn returns the URLs of the relays the event was received from, in the
+// order it was received. It returns nil when the event is unknown or
+// deduplication is disabled.
+func (cl *Client) SeenOn(id string) []string {
+	if cl.events == nil {
+		return nil
+	}
+	return cl.events.relays(id)
+}
+
+// firstSeen records the relay an event message was received from and reports
+// whether it is the first copy of the event for its subscription. Other
+// messages, and all messages when deduplication is disabled, are first seen.
+func (cl *Client) firstSeen(r *Relay, env message.Envelope) bool {
+	evt, ok := env.(*message.EventEnvelope)
+	if !ok || evt.Event == nil || cl.events == nil {
+		return true
 	}
+	return cl.events.record(evt.Event.ID, r.url, evt.SubscriptionID)
+}
+
+// report sends the error on the error channel without blocking, dropping the
+// oldest errors when it is full.
+func (cl *Client) report(err error) {
+	dropOldest(cl.errCh, err)
 }
@@ -2,17 +2,28 @@ package client_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/go-nostr/nostr/client"
+	"github.com/go-nostr/nostr/event"
 	"github.com/go-nostr/nostr/message"
 	"github.com/go-nostr/nostr/message/closemessage"
+	"github.com/go-nostr/nostr/message/eventmessage"
 	"github.com/go-nostr/nostr/message/requestmessage"
 	"github.com/go-nostr/nostr/relay"
 	"github.com/go-nostr/nostr/subscriptionid"
+	"nhooyr.io/websocket"
 )
 
 func Test_New(t *testing.T) {
@@ -122,7 +133,7 @@ func TestClient_SendMessage(t *testing.T) {
 		{
 			name: "SHOULD send close message from client to relay",
 			args: args{
-				msg: closemessage.New(),
+				msg: closemessage.New(subscriptionid.New()),
 			},
 		},
 		// FIX: failing assertion comparing pointer to map
@@ -147,7 +158,7 @@ func TestClient_SendMessage(t *testing.T) {
 			rl.HandleErrorFunc(func(err error) {
 				errCh <- err
 			})
-			rl.HandleMessageFunc(func(msg message.Message) {
+			rl.HandleMessageFunc(func(ctx context.Context, c *relay.Conn, msg message.Message) {
 				msgCh <- msg
 			})
 			ts := httptest.NewServer(rl)
@@ -161,8 +172,12 @@ func TestClient_SendMessage(t *testing.T) {
 			cl.HandleMessageFunc(func(msg message.Message) {
 				t.Log(msg)
 			})
-			cl.Connect(ctx, ts.URL)
-			cl.SendMessage(ctx, tt.args.msg)
+			if err := cl.Connect(ctx, ts.URL); err != nil {
+				t.Fatal(err)
+			}
+			if err := cl.SendMessage(ctx, tt.args.msg); err != nil {
+				t.Fatal(err)
+			}
 			select {
 			case err := <-errCh:
 				t.Error(err)
@@ -183,7 +198,7 @@ func TestClient_Connect(t *testing.T) {
 	rl.HandleErrorFunc(func(err error) {
 		// t.Error(err)
 	})
-	rl.HandleMessageFunc(func(msg message.Message) {
+	rl.HandleMessageFunc(func(ctx context.Context, c *relay.Conn, msg message.Message) {
 		t.Log(msg)
 	})
 	ts := httptest.NewServer(rl)
@@ -198,6 +213,7 @@ func TestClient_Connect(t *testing.T) {
 		name   string
 		args   args
 		fields fields
+		err    bool
 	}{
 		{
 			name: "SHOULD subscribe to relay",
@@ -208,6 +224,20 @@ func TestClient_Connect(t *testing.T) {
 				u: ts.URL,
 			},
 		},
+		{
+			name: "SHOULD return error without listening WHEN relay is unreachable",
+			fields: fields{
+				u: "ws://127.0.0.1:1",
+			},
+			err: true,
+		},
+		{
+			name: "SHOULD return error WHEN url is invalid",
+			fields: fields{
+				u: "ftp://relay.example.com",
+			},
+			err: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -220,7 +250,1112 @@ func TestClient_Connect(t *testing.T) {
 			cl.HandleMessageFunc(func(msg message.Message) {
 				t.Log(msg)
 			})
-			cl.Connect(ctx, tt.fields.u)
+			err := cl.Connect(ctx, tt.fields.u)
+			t.Logf("got %v", err)
+			if (err != nil) != tt.err {
+				t.Errorf("expected error %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestClient_Auth(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rl := relay.New(&relay.Options{AuthRequiredKinds: []int{4}})
+	rl.HandleErrorFunc(func(err error) {})
+	ts := httptest.NewServer(rl)
+	defer ts.Close()
+	dm := event.New(4, "secret")
+	if err := dm.SignWith(ctx, signer); err != nil {
+		t.Fatal(err)
+	}
+	msgCh := make(chan message.Message, 16)
+	cl := client.New(&client.Options{Signer: signer})
+	cl.HandleErrorFunc(func(err error) {
+		t.Error(err)
+	})
+	cl.HandleMessageFunc(func(msg message.Message) {
+		msgCh <- msg
+	})
+	go cl.Listen(ctx)
+	if err := cl.Connect(ctx, ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.SendMessage(ctx, message.New("EVENT", dm)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.SendMessage(ctx, message.New("REQ", "dms", map[string]any{"kinds": []int{4}})); err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]bool{"published": false, "received": false, "eose": false}
+	for !expect["published"] || !expect["received"] || !expect["eose"] {
+		select {
+		case msg := <-msgCh:
+			t.Logf("got %v", msg)
+			switch {
+			case msg[0] == "OK" && msg[1] == dm.ID && msg[2] == true:
+				expect["published"] = true
+			case msg[0] == "EVENT" && msg[1] == "dms":
+				expect["received"] = true
+			case msg[0] == "EOSE" && msg[1] == "dms":
+				expect["eose"] = true
+			}
+		case <-ctx.Done():
+			t.Fatalf("expected %v, got %v", "all true", expect)
+		}
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		u      string
+		expect string
+		err    bool
+	}{
+		{
+			name:   "SHOULD lowercase scheme and host and trim trailing slash",
+			u:      "WSS://Relay.Example.com/",
+			expect: "wss://relay.example.com",
+		},
+		{
+			name:   "SHOULD remove default port",
+			u:      "wss://relay.example.com:443/nostr/",
+			expect: "wss://relay.example.com/nostr",
+		},
+		{
+			name:   "SHOULD map http to ws",
+			u:      "http://127.0.0.1:8080",
+			expect: "ws://127.0.0.1:8080",
+		},
+		{
+			name: "SHOULD reject unsupported scheme",
+			u:    "ftp://relay.example.com",
+			err:  true,
+		},
+		{
+			name: "SHOULD reject missing host",
+			u:    "wss://",
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.NormalizeURL(tt.u)
+			t.Logf("got %v, %v", got, err)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if got != tt.expect {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestClient_Pool(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	servers := make([]*httptest.Server, 2)
+	urls := make([]string, 2)
+	for i := range servers {
+		rl := relay.New(nil)
+		rl.HandleErrorFunc(func(err error) {})
+		servers[i] = httptest.NewServer(rl)
+		defer servers[i].Close()
+		u, err := client.NormalizeURL(servers[i].URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		urls[i] = u
+	}
+	type relayMessage struct {
+		url string
+		msg message.Message
+	}
+	msgCh := make(chan relayMessage, 16)
+	cl := client.New(nil)
+	cl.HandleErrorFunc(func(err error) {})
+	cl.HandleMessageFunc(func(msg message.Message) {})
+	cl.HandleRelayMessageFunc(func(r *client.Relay, msg message.Message) {
+		msgCh <- relayMessage{url: r.URL(), msg: msg}
+	})
+	go cl.Listen(ctx)
+	if err := cl.Connect(ctx, servers[0].URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.Connect(ctx, servers[0].URL+"/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.Connect(ctx, servers[1].URL); err != nil {
+		t.Fatal(err)
+	}
+	relays := cl.Relays()
+	if len(relays) != 2 {
+		t.Fatalf("expected %v relays, got %v", 2, len(relays))
+	}
+	for _, r := range relays {
+		if r.Status() != client.StatusConnected {
+			t.Errorf("expected %v, got %v", client.StatusConnected, r.Status())
+		}
+	}
+
+	t.Run("SHOULD send message to targeted relay only", func(t *testing.T) {
+		if err := cl.SendMessageTo(ctx, requestmessage.New("targeted"), urls[1]); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case rm := <-msgCh:
+			t.Logf("got %v from %v", rm.msg, rm.url)
+			if rm.url != urls[1] || rm.msg[0] != "EOSE" || rm.msg[1] != "targeted" {
+				t.Errorf("expected %v from %v, got %v from %v", "EOSE", urls[1], rm.msg, rm.url)
+			}
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+		if subs := cl.Relay(urls[1]).Subscriptions(); !reflect.DeepEqual(subs, []string{"targeted"}) {
+			t.Errorf("expected %v, got %v", []string{"targeted"}, subs)
+		}
+		if subs := cl.Relay(urls[0]).Subscriptions(); len(subs) != 0 {
+			t.Errorf("expected no subscriptions, got %v", subs)
+		}
+	})
+
+	t.Run("SHOULD disconnect relay", func(t *testing.T) {
+		r := cl.Relay(urls[0])
+		if err := cl.Disconnect(urls[0]); err != nil {
+			t.Fatal(err)
+		}
+		if r.Status() != client.StatusClosed {
+			t.Errorf("expected %v, got %v", client.StatusClosed, r.Status())
+		}
+		if relays := cl.Relays(); len(relays) != 1 || relays[0].URL() != urls[1] {
+			t.Errorf("expected only %v, got %v", urls[1], relays)
+		}
+		if err := r.Send(ctx, requestmessage.New("closed")); !errors.Is(err, client.ErrNotConnected) {
+			t.Errorf("expected %v, got %v", client.ErrNotConnected, err)
+		}
+		if err := cl.Disconnect(urls[0]); !errors.Is(err, client.ErrNotConnected) {
+			t.Errorf("expected %v, got %v", client.ErrNotConnected, err)
+		}
+	})
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff *client.Backoff
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{
+			name:    "SHOULD wait minimum delay before first attempt",
+			backoff: &client.Backoff{Min: time.Second, Max: time.Minute, Factor: 2},
+			attempt: 0,
+			min:     time.Second,
+			max:     time.Second,
+		},
+		{
+			name:    "SHOULD grow delay exponentially",
+			backoff: &client.Backoff{Min: time.Second, Max: time.Minute, Factor: 2},
+			attempt: 3,
+			min:     8 * time.Second,
+			max:     8 * time.Second,
+		},
+		{
+			name:    "SHOULD cap delay to maximum",
+			backoff: &client.Backoff{Min: time.Second, Max: time.Minute, Factor: 2},
+			attempt: 20,
+			min:     time.Minute,
+			max:     time.Minute,
+		},
+		{
+			name:    "SHOULD remove jitter from delay",
+			backoff: &client.Backoff{Min: time.Second, Max: time.Minute, Factor: 2, Jitter: 0.5},
+			attempt: 2,
+			min:     2 * time.Second,
+			max:     4 * time.Second,
+		},
+		{
+			name:    "SHOULD cap delay to longest duration WHEN maximum is not set",
+			backoff: &client.Backoff{Min: time.Second, Factor: 2},
+			attempt: 5000,
+			min:     time.Duration(math.MaxInt64),
+			max:     time.Duration(math.MaxInt64),
+		},
+		{
+			name:    "SHOULD keep jittered delay positive WHEN maximum is not set",
+			backoff: &client.Backoff{Min: time.Second, Factor: 2, Jitter: 0.5},
+			attempt: 5000,
+			min:     time.Duration(math.MaxInt64 / 2),
+			max:     time.Duration(math.MaxInt64),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				got := tt.backoff.Delay(tt.attempt)
+				if got < tt.min || got > tt.max {
+					t.Fatalf("expected between %v and %v, got %v", tt.min, tt.max, got)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_Reconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := event.New(1, "stored")
+	stored.CreatedAt = 100
+	if err := stored.SignWith(ctx, signer); err != nil {
+		t.Fatal(err)
+	}
+	queued := event.New(1, "queued")
+	if err := queued.SignWith(ctx, signer); err != nil {
+		t.Fatal(err)
+	}
+	connCh := make(chan *relay.Conn, 4)
+	msgCh := make(chan message.Message, 16)
+	rl := relay.New(nil)
+	rl.HandleErrorFunc(func(err error) {})
+	rl.HandleConnectFunc(func(ctx context.Context, c *relay.Conn) {
+		connCh <- c
+	})
+	rl.HandleMessageFunc(func(ctx context.Context, c *relay.Conn, msg message.Message) {
+		msgCh <- msg
+	})
+	if err := rl.Store.Save(ctx, stored); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(rl)
+	defer ts.Close()
+	cl := client.New(&client.Options{
+		Backoff:      &client.Backoff{Min: 200 * time.Millisecond, Max: time.Second, Factor: 2},
+		PingInterval: 50 * time.Millisecond,
+	})
+	cl.HandleErrorFunc(func(err error) {
+		t.Logf("got %v", err)
+	})
+	cl.HandleMessageFunc(func(msg message.Message) {})
+	go cl.Listen(ctx)
+	if err := cl.Connect(ctx, ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.SendMessage(ctx, message.New("REQ", "notes", map[string]any{"kinds": []int{1}})); err != nil {
+		t.Fatal(err)
+	}
+	receive := func(typ string) message.Message {
+		for {
+			select {
+			case msg := <-msgCh:
+				if msg[0] == typ {
+					return msg
+				}
+			case <-ctx.Done():
+				t.Fatalf("expected %v, got %v", typ, ctx.Err())
+			}
+		}
+	}
+	receive("REQ")
+	time.Sleep(200 * time.Millisecond)
+	r := cl.Relay(ts.URL)
+	if r.Status() != client.StatusConnected {
+		t.Fatalf("expected %v, got %v", client.StatusConnected, r.Status())
+	}
+	go (<-connCh).Close("restarting")
+	for r.Status() != client.StatusBackoff {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("expected %v, got %v", client.StatusBackoff, r.Status())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if err := cl.SendMessage(ctx, message.New("EVENT", queued)); err != nil {
+		t.Fatal(err)
+	}
+	req := receive("REQ")
+	t.Logf("got %v", req)
+	if filter := mustFilter(t, req[2]); filter.Since != stored.CreatedAt {
+		t.Errorf("expected since %v, got %v", stored.CreatedAt, filter.Since)
+	}
+	if msg := receive("EVENT"); !reflect.DeepEqual(mustMapOf(t, msg[1]), mustMapOf(t, queued)) {
+		t.Errorf("expected %v, got %v", queued, msg[1])
+	}
+	if r.Status() != client.StatusConnected {
+		t.Errorf("expected %v, got %v", client.StatusConnected, r.Status())
+	}
+}
+
+func mustFilter(t *testing.T, v any) *message.Filter {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f message.Filter
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatal(err)
+	}
+	return &f
+}
+
+func mustMapOf(t *testing.T, v any) map[string]any {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newNote := func(content string, createdAt int) *event.Event {
+		evt := event.New(1, content)
+		evt.CreatedAt = createdAt
+		if err := evt.SignWith(ctx, signer); err != nil {
+			t.Fatal(err)
+		}
+		return evt
+	}
+	stored := []*event.Event{newNote("first", 100), newNote("second", 200)}
+	live := newNote("live", 300)
+	closeCh := make(chan message.Message, 1)
+	rl := relay.New(&relay.Options{
+		RejectFilter: []relay.RejectFilterFunc{
+			func(ctx context.Context, c *relay.Conn, f *message.Filter) string {
+				if len(f.Kinds) == 1 && f.Kinds[0] == 4 {
+					return "restricted: no direct messages"
+				}
+				return ""
+			},
+		},
+	})
+	rl.HandleErrorFunc(func(err error) {})
+	rl.HandleMessageFunc(func(ctx context.Context, c *relay.Conn, msg message.Message) {
+		if msg[0] == "CLOSE" {
+			closeCh <- msg
+		}
+	})
+	for _, evt := range stored {
+		if err := rl.Store.Save(ctx, evt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(rl)
+	defer ts.Close()
+	cl := client.New(nil)
+	cl.HandleErrorFunc(func(err error) {})
+	cl.HandleMessageFunc(func(msg message.Message) {})
+	go cl.Listen(ctx)
+	if err := cl.Connect(ctx, ts.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("SHOULD receive stored and live events", func(t *testing.T) {
+		sub, err := cl.Subscribe(ctx, message.Filters{{Kinds: []int{1}}}, &client.SubscribeOptions{ID: "notes"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for len(got) < 2 {
+			select {
+			case evt := <-sub.Events:
+				got = append(got, evt.Content)
+			case <-ctx.Done():
+				t.Fatalf("expected %v, got %v", 2, got)
+			}
+		}
+		select {
+		case <-sub.EOSE:
+		case <-ctx.Done():
+			t.Fatalf("expected EOSE, got %v", ctx.Err())
+		}
+		if expect := []string{"second", "first"}; !reflect.DeepEqual(expect, got) {
+			t.Errorf("expected %v, got %v", expect, got)
+		}
+		if err := cl.SendMessage(ctx, message.New("EVENT", live)); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case evt := <-sub.Events:
+			if evt.ID != live.ID {
+				t.Errorf("expected %v, got %v", live.ID, evt.ID)
+			}
+		case <-ctx.Done():
+			t.Fatalf("expected %v, got %v", live.ID, ctx.Err())
+		}
+		if err := sub.Unsubscribe(); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case msg := <-closeCh:
+			if msg[1] != "notes" {
+				t.Errorf("expected %v, got %v", "notes", msg[1])
+			}
+		case <-ctx.Done():
+			t.Fatalf("expected CLOSE, got %v", ctx.Err())
+		}
+		<-sub.Closed
+		for evt := range sub.Events {
+			t.Errorf("expected no event, got %v", evt)
+		}
+	})
+
+	t.Run("SHOULD report reason of subscription closed by relay", func(t *testing.T) {
+		sub, err := cl.Subscribe(ctx, message.Filters{{Kinds: []int{4}}}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-sub.Closed:
+		case <-ctx.Done():
+			t.Fatalf("expected closed, got %v", ctx.Err())
+		}
+		if expect := "restricted: no direct messages"; sub.Reason() != expect {
+			t.Errorf("expected %v, got %v", expect, sub.Reason())
+		}
+	})
+
+	t.Run("SHOULD reject unknown relay", func(t *testing.T) {
+		_, err := cl.Subscribe(ctx, message.Filters{{}}, &client.SubscribeOptions{Relays: []string{"wss://unknown.example.com"}})
+		t.Logf("got %v", err)
+		if !errors.Is(err, client.ErrNotConnected) {
+			t.Errorf("expected %v, got %v", client.ErrNotConnected, err)
+		}
+	})
+}
+
+func TestClient_Publish(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	evt := event.New(1, "hello")
+	if err := evt.SignWith(ctx, signer); err != nil {
+		t.Fatal(err)
+	}
+	accepting := relay.New(nil)
+	accepting.HandleErrorFunc(func(err error) {})
+	rejecting := relay.New(&relay.Options{
+		RejectEvent: []relay.RejectEventFunc{
+			func(ctx context.Context, c *relay.Conn, evt *event.Event) string {
+				return "blocked: no notes here"
+			},
+		},
+	})
+	rejecting.HandleErrorFunc(func(err error) {})
+	silent := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close(websocket.StatusNormalClosure, "")
+		for {
+			if _, _, err := ws.Read(r.Context()); err != nil {
+				return
+			}
+		}
+	})
+	reconnected := relay.New(nil)
+	reconnected.HandleErrorFunc(func(err error) {})
+	var dropped atomic.Bool
+	dropping := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if dropped.Load() {
+			reconnected.ServeHTTP(w, r)
+			return
+		}
+		ws, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		// drop the connection once the event arrives, before answering it
+		if _, _, err := ws.Read(r.Context()); err == nil {
+			dropped.Store(true)
+			ws.Close(websocket.StatusGoingAway, "restarting")
+		}
+	})
+	var urls []string
+	for _, h := range []http.Handler{accepting, rejecting, silent, dropping} {
+		ts := httptest.NewServer(h)
+		defer ts.Close()
+		u, err := client.NormalizeURL(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		urls = append(urls, u)
+	}
+	unknown := "wss://unknown.example.com"
+	cl := client.New(&client.Options{
+		Backoff:        &client.Backoff{Min: 10 * time.Millisecond, Max: 10 * time.Millisecond, Factor: 1},
+		PublishTimeout: 300 * time.Millisecond,
+	})
+	cl.HandleErrorFunc(func(err error) {})
+	cl.HandleMessageFunc(func(msg message.Message) {})
+	go cl.Listen(ctx)
+	for _, u := range urls {
+		if err := cl.Connect(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	results := cl.Publish(ctx, evt, append(urls, unknown)...)
+	expect := []struct {
+		relay  string
+		status client.PublishStatus
+		prefix string
+	}{
+		{relay: urls[0], status: client.PublishAccepted},
+		{relay: urls[1], status: client.PublishRejected, prefix: "blocked"},
+		{relay: urls[2], status: client.PublishTimedOut},
+		{relay: urls[3], status: client.PublishAccepted},
+		{relay: unknown, status: client.PublishFailed},
+	}
+	if len(results) != len(expect) {
+		t.Fatalf("expected %v results, got %v", len(expect), len(results))
+	}
+	for i, res := range results {
+		t.Logf("got %v", res)
+		if res.Relay != expect[i].relay || res.Status != expect[i].status || res.Prefix != expect[i].prefix {
+			t.Errorf("expected %+v, got %+v", expect[i], res)
+		}
+	}
+}
+
+func TestClient_Overflow(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rl := relay.New(nil)
+	rl.HandleErrorFunc(func(err error) {})
+	for i := 1; i <= 5; i++ {
+		evt := event.New(1, fmt.Sprintf("note %d", i))
+		evt.CreatedAt = i * 100
+		if err := evt.SignWith(ctx, signer); err != nil {
+			t.Fatal(err)
+		}
+		if err := rl.Store.Save(ctx, evt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(rl)
+	defer ts.Close()
+
+	t.Run("SHOULD drop oldest events of subscription", func(t *testing.T) {
+		cl := client.New(nil)
+		if err := cl.Connect(ctx, ts.URL); err != nil {
+			t.Fatal(err)
+		}
+		sub, err := cl.Subscribe(ctx, message.Filters{{Kinds: []int{1}}}, &client.SubscribeOptions{BufferSize: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-sub.EOSE
+		sub.Unsubscribe()
+		var got []string
+		for evt := range sub.Events {
+			got = append(got, evt.Content)
+		}
+		if expect := []string{"note 2", "note 1"}; !reflect.DeepEqual(expect, got) {
+			t.Errorf("expected %v, got %v", expect, got)
+		}
+	})
+
+	t.Run("SHOULD close subscription WHEN policy is disconnect", func(t *testing.T) {
+		cl := client.New(nil)
+		if err := cl.Connect(ctx, ts.URL); err != nil {
+			t.Fatal(err)
+		}
+		sub, err := cl.Subscribe(ctx, message.Filters{{Kinds: []int{1}}}, &client.SubscribeOptions{BufferSize: 2, Overflow: client.OverflowDisconnect})
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-sub.Closed:
+		case <-ctx.Done():
+			t.Fatalf("expected closed, got %v", ctx.Err())
+		}
+		if expect := "error: buffer overflow"; sub.Reason() != expect {
+			t.Errorf("expected %v, got %v", expect, sub.Reason())
+		}
+	})
+
+	t.Run("SHOULD disconnect relay WHEN message buffer is full and policy is disconnect", func(t *testing.T) {
+		cl := client.New(&client.Options{BufferSize: 2, Overflow: client.OverflowDisconnect})
+		if err := cl.Connect(ctx, ts.URL); err != nil {
+			t.Fatal(err)
+		}
+		if err := cl.SendMessage(ctx, requestmessage.New("notes", &message.Filter{Kinds: []int{1}})); err != nil {
+			t.Fatal(err)
+		}
+		r := cl.Relay(ts.URL)
+		for r.Status() != client.StatusClosed {
+			select {
+			case <-ctx.Done():
+				t.Fatalf("expected %v, got %v", client.StatusClosed, r.Status())
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		errCh := make(chan error, 1)
+		cl.HandleErrorFunc(func(err error) {
+			if errors.Is(err, client.ErrOverflow) {
+				errCh <- err
+			}
+		})
+		cl.HandleMessageFunc(func(msg message.Message) {})
+		go cl.Listen(ctx)
+		select {
+		case err := <-errCh:
+			t.Logf("got %v", err)
+		case <-ctx.Done():
+			t.Fatalf("expected %v, got %v", client.ErrOverflow, ctx.Err())
+		}
+	})
+}
+
+func TestClient_Listen_SlowHandler(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rl := relay.New(nil)
+	rl.HandleErrorFunc(func(err error) {})
+	for i := 1; i <= 5; i++ {
+		evt := event.New(1, fmt.Sprintf("note %d", i))
+		evt.CreatedAt = i * 100
+		if err := evt.SignWith(ctx, signer); err != nil {
+			t.Fatal(err)
+		}
+		if err := rl.Store.Save(ctx, evt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(rl)
+	defer ts.Close()
+
+	t.Run("SHOULD handle messages in order WHEN handler is slow", func(t *testing.T) {
+		cl := client.New(&client.Options{BufferSize: 1, Overflow: client.OverflowBlock})
+		gotCh := make(chan string, 6)
+		cl.HandleMessageFunc(func(msg message.Message) {
+			time.Sleep(5 * time.Millisecond)
+			env, err := message.ParseMessage(msg)
+			if err != nil {
+				return
+			}
+			if evt, ok := env.(*message.EventEnvelope); ok {
+				gotCh <- evt.Event.Content
+			} else {
+				gotCh <- msg[0].(string)
+			}
+		})
+		go cl.Listen(ctx)
+		if err := cl.Connect(ctx, ts.URL); err != nil {
+			t.Fatal(err)
+		}
+		if err := cl.SendMessage(ctx, requestmessage.New("notes", &message.Filter{Kinds: []int{1}})); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for len(got) < 6 {
+			select {
+			case s := <-gotCh:
+				got = append(got, s)
+			case <-ctx.Done():
+				t.Fatalf("expected 6 messages, got %v", got)
+			}
+		}
+		if expect := []string{"note 5", "note 4", "note 3", "note 2", "note 1", "EOSE"}; !reflect.DeepEqual(expect, got) {
+			t.Errorf("expected %v, got %v", expect, got)
+		}
+	})
+
+	t.Run("SHOULD apply overflow policy WHEN handler is slow", func(t *testing.T) {
+		cl := client.New(&client.Options{BufferSize: 1, Overflow: client.OverflowDisconnect})
+		release := make(chan struct{})
+		cl.HandleMessageFunc(func(msg message.Message) {
+			<-release
+		})
+		errCh := make(chan error, 10)
+		cl.HandleErrorFunc(func(err error) {
+			errCh <- err
+		})
+		go cl.Listen(ctx)
+		if err := cl.Connect(ctx, ts.URL); err != nil {
+			t.Fatal(err)
+		}
+		if err := cl.SendMessage(ctx, requestmessage.New("notes", &message.Filter{Kinds: []int{1}})); err != nil {
+			t.Fatal(err)
+		}
+		r := cl.Relay(ts.URL)
+		for r.Status() != client.StatusClosed {
+			select {
+			case <-ctx.Done():
+				t.Fatalf("expected %v, got %v", client.StatusClosed, r.Status())
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		close(release)
+		for {
+			select {
+			case err := <-errCh:
+				if errors.Is(err, client.ErrOverflow) {
+					t.Logf("got %v", err)
+					return
+				}
+			case <-ctx.Done():
+				t.Fatalf("expected %v, got %v", client.ErrOverflow, ctx.Err())
+			}
+		}
+	})
+}
+
+func TestClient_PendingEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	connected := make(chan struct{}, 4)
+	var received atomic.Int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		received.Store(0)
+		connected <- struct{}{}
+		for {
+			_, data, err := ws.Read(r.Context())
+			if err != nil {
+				return
+			}
+			// closing from the read loop completes the close handshake
+			if string(data) == `["RESTART"]` {
+				ws.Close(websocket.StatusGoingAway, "restarting")
+				return
+			}
+			if strings.HasPrefix(string(data), `["EVENT"`) {
+				received.Add(1)
+			}
+		}
+	}))
+	defer ts.Close()
+	cl := client.New(&client.Options{
+		Backoff: &client.Backoff{Min: 10 * time.Millisecond, Max: 10 * time.Millisecond, Factor: 1},
+	})
+	cl.HandleErrorFunc(func(err error) {})
+	cl.HandleMessageFunc(func(msg message.Message) {})
+	go cl.Listen(ctx)
+	if err := cl.Connect(ctx, ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	<-connected
+	r := cl.Relay(ts.URL)
+
+	t.Run("SHOULD bound events waiting for an OK message", func(t *testing.T) {
+		for i := 0; i < client.MaxPendingEvents+10; i++ {
+			evt := event.New(1, "unacknowledged")
+			evt.ID = fmt.Sprintf("%064x", i)
+			msg, _ := eventmessage.New("", evt)
+			if err := r.Send(ctx, msg); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := r.PendingEvents(); got != client.MaxPendingEvents {
+			t.Errorf("expected %v, got %v", client.MaxPendingEvents, got)
+		}
+	})
+
+	t.Run("SHOULD resend events waiting for an OK message WHEN reconnecting", func(t *testing.T) {
+		if err := r.Send(ctx, message.New("RESTART")); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-connected:
+		case <-ctx.Done():
+			t.Fatalf("expected reconnection, got %v", ctx.Err())
+		}
+		for received.Load() != client.MaxPendingEvents {
+			select {
+			case <-ctx.Done():
+				t.Fatalf("expected %v, got %v", client.MaxPendingEvents, received.Load())
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		if got := r.PendingEvents(); got != client.MaxPendingEvents {
+			t.Errorf("expected %v, got %v", client.MaxPendingEvents, got)
+		}
+	})
+}
+
+func TestClient_QueueSize(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		ws.Close(websocket.StatusGoingAway, "restarting")
+	}))
+	defer ts.Close()
+	cl := client.New(&client.Options{
+		Backoff:   &client.Backoff{Min: time.Minute, Max: time.Minute, Factor: 1},
+		QueueSize: 2,
+	})
+	cl.HandleErrorFunc(func(err error) {})
+	cl.HandleMessageFunc(func(msg message.Message) {})
+	go cl.Listen(ctx)
+	if err := cl.Connect(ctx, ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	r := cl.Relay(ts.URL)
+	for r.Status() != client.StatusBackoff {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("expected %v, got %v", client.StatusBackoff, r.Status())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	t.Run("SHOULD queue events WHEN queue is not full", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if err := r.Send(ctx, message.New("EVENT", event.New(1, "queued"))); err != nil {
+				t.Fatalf("expected %v, got %v", nil, err)
+			}
+		}
+	})
+
+	t.Run("SHOULD drop events WHEN queue is full", func(t *testing.T) {
+		err := r.Send(ctx, message.New("EVENT", event.New(1, "dropped")))
+		t.Logf("got %v", err)
+		if !errors.Is(err, client.ErrQueueFull) {
+			t.Errorf("expected %v, got %v", client.ErrQueueFull, err)
+		}
+	})
+
+	t.Run("SHOULD record subscriptions WHEN queue is full", func(t *testing.T) {
+		if err := r.Send(ctx, message.New("REQ", "notes", map[string]any{"kinds": []int{1}})); err != nil {
+			t.Errorf("expected %v, got %v", nil, err)
+		}
+	})
+}
+
+func TestClient_PendingRetries(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	connected := make(chan struct{}, 4)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		connected <- struct{}{}
+		for {
+			_, data, err := ws.Read(r.Context())
+			if err != nil {
+				return
+			}
+			// closing from the read loop completes the close handshake
+			if string(data) == `["RESTART"]` {
+				ws.Close(websocket.StatusGoingAway, "restarting")
+				return
+			}
+			var msg []json.RawMessage
+			var evt event.Event
+			if json.Unmarshal(data, &msg) != nil || len(msg) != 2 || json.Unmarshal(msg[1], &evt) != nil {
+				continue
+			}
+			ok := fmt.Sprintf(`["OK",%q,false,"auth-required: no challenge for you"]`, evt.ID)
+			if err := ws.Write(r.Context(), websocket.MessageText, []byte(ok)); err != nil {
+				return
+			}
+		}
+	}))
+	defer ts.Close()
+	msgCh := make(chan message.Message, 16)
+	cl := client.New(&client.Options{
+		Signer:    signer,
+		Backoff:   &client.Backoff{Min: 10 * time.Millisecond, Max: 10 * time.Millisecond, Factor: 1},
+		QueueSize: 2,
+	})
+	cl.HandleErrorFunc(func(err error) {})
+	cl.HandleMessageFunc(func(msg message.Message) {
+		msgCh <- msg
+	})
+	go cl.Listen(ctx)
+	if err := cl.Connect(ctx, ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	<-connected
+	r := cl.Relay(ts.URL)
+	for r.Status() != client.StatusConnected {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("expected %v, got %v", client.StatusConnected, r.Status())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	t.Run("SHOULD bound messages waiting for authentication", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			evt := event.New(1, "unauthenticated")
+			evt.ID = fmt.Sprintf("%064x", i)
+			msg, _ := eventmessage.New("", evt)
+			if err := r.Send(ctx, msg); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := 0; i < 3; i++ {
+			select {
+			case msg := <-msgCh:
+				t.Logf("got %v", msg)
+			case <-ctx.Done():
+				t.Fatalf("expected OK message, got %v", ctx.Err())
+			}
+		}
+		if got := r.PendingRetries(); got != 2 {
+			t.Errorf("expected %v, got %v", 2, got)
+		}
+	})
+
+	t.Run("SHOULD drop messages waiting for authentication WHEN disconnected", func(t *testing.T) {
+		if err := r.Send(ctx, message.New("RESTART")); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-connected:
+		case <-ctx.Done():
+			t.Fatalf("expected reconnection, got %v", ctx.Err())
+		}
+		if got := r.PendingRetries(); got != 0 {
+			t.Errorf("expected %v, got %v", 0, got)
+		}
+	})
+}
+
+func TestClient_SeenOn(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var notes []*event.Event
+	for i := 1; i <= 2; i++ {
+		evt := event.New(1, fmt.Sprintf("note %d", i))
+		evt.CreatedAt = i * 100
+		if err := evt.SignWith(ctx, signer); err != nil {
+			t.Fatal(err)
+		}
+		notes = append(notes, evt)
+	}
+	var urls []string
+	for i := 0; i < 2; i++ {
+		rl := relay.New(nil)
+		rl.HandleErrorFunc(func(err error) {})
+		for _, evt := range notes {
+			if err := rl.Store.Save(ctx, evt); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ts := httptest.NewServer(rl)
+		defer ts.Close()
+		u, err := client.NormalizeURL(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	tests := []struct {
+		name       string
+		dedupeSize int
+		relays     []string
+		events     int
+		seenOn     map[string][]string
+	}{
+		{
+			name:       "SHOULD deliver each event once and record relays",
+			dedupeSize: 10,
+			relays:     urls,
+			events:     2,
+			seenOn:     map[string][]string{notes[0].ID: urls, notes[1].ID: urls},
+		},
+		{
+			name:       "SHOULD forget least recently seen events",
+			dedupeSize: 1,
+			relays:     urls[:1],
+			events:     2,
+			seenOn:     map[string][]string{notes[1].ID: nil, notes[0].ID: urls[:1]},
+		},
+		{
+			name:       "SHOULD deliver every copy WHEN deduplication is disabled",
+			dedupeSize: 0,
+			relays:     urls,
+			events:     4,
+			seenOn:     map[string][]string{notes[0].ID: nil, notes[1].ID: nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := client.New(&client.Options{DedupeSize: tt.dedupeSize})
+			for _, u := range tt.relays {
+				if err := cl.Connect(ctx, u); err != nil {
+					t.Fatal(err)
+				}
+			}
+			sub, err := cl.Subscribe(ctx, message.Filters{{Kinds: []int{1}}}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-sub.EOSE:
+			case <-ctx.Done():
+				t.Fatalf("expected EOSE, got %v", ctx.Err())
+			}
+			sub.Unsubscribe()
+			got := 0
+			for range sub.Events {
+				got++
+			}
+			if got != tt.events {
+				t.Errorf("expected %v events, got %v", tt.events, got)
+			}
+			for id, expect := range tt.seenOn {
+				seenOn := cl.SeenOn(id)
+				sort.Strings(seenOn)
+				if !reflect.DeepEqual(expect, seenOn) {
+					t.Errorf("expected %v, got %v", expect, seenOn)
+				}
+			}
 		})
 	}
 }
@@ -0,0 +1,19 @@
+package client
+
+// MaxPendingEvents is exposed to the tests of the pending events.
+const MaxPendingEvents = maxPendingEvents
+
+// PendingEvents returns the number of events waiting for an OK message.
+func (r *Relay) PendingEvents() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events.len()
+}
+
+// PendingRetries returns the number of messages waiting to be sent again
+// after authenticating.
+func (r *Relay) PendingRetries() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.retries)
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrOverflow is reported when a buffer of received messages is full and the
+// Overflow policy is OverflowDisconnect.
+var ErrOverflow = errors.New("buffer overflow")
+
+// Overflow is the policy applied when a buffer of received messages is full.
+type Overflow int
+
+// Overflow policies. The zero value drops the oldest message.
+const (
+	OverflowDropOldest Overflow = iota // OverflowDropOldest drops the oldest buffered message.
+	OverflowBlock                      // OverflowBlock waits for the consumer, stalling the relay connection.
+	OverflowDisconnect                 // OverflowDisconnect closes the subscription or the relay connection.
+)
+
+// String returns the name of the policy.
+func (o Overflow) String() string {
+	switch o {
+	case OverflowDropOldest:
+		return "drop oldest"
+	case OverflowBlock:
+		return "block"
+	case OverflowDisconnect:
+		return "disconnect"
+	}
+	return fmt.Sprintf("Overflow(%d)", int(o))
+}
+
+// dropOldest sends v on the channel without blocking, dropping the oldest
+// buffered values to make room.
+func dropOldest[T any](ch chan T, v T) {
+	for {
+		select {
+		case ch <- v:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+// tryPush sends v on the channel without blocking, and reports whether it did.
+func tryPush[T any](ch chan T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"container/list"
+
+	"github.com/go-nostr/nostr/message"
+)
+
+// maxPendingEvents is the number of events sent to a relay that are remembered
+// until the relay answers with an OK message.
+const maxPendingEvents = 1000
+
+// pending is a bounded FIFO of the event messages sent to a relay and not yet
+// acknowledged, keyed by event ID. When it is full the oldest event is
+// forgotten, so events never acknowledged by the relay do not pile up. It is
+// guarded by the mutex of the relay.
+type pending struct {
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+// pendingEntry is an event message of the pending FIFO.
+type pendingEntry struct {
+	id  string
+	msg message.Message
+}
+
+// newPending creates a pending FIFO holding up to capacity events.
+func newPending(capacity int) *pending {
+	return &pending{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// add remembers the event message sent for the event ID.
+func (p *pending) add(id string, msg message.Message) {
+	if el, ok := p.entries[id]; ok {
+		p.order.Remove(el)
+	}
+	p.entries[id] = p.order.PushBack(&pendingEntry{id: id, msg: msg})
+	if p.order.Len() > p.capacity {
+		oldest := p.order.Front()
+		p.order.Remove(oldest)
+		delete(p.entries, oldest.Value.(*pendingEntry).id)
+	}
+}
+
+// len returns the number of pending events.
+func (p *pending) len() int {
+	return p.order.Len()
+}
+
+// drain removes and returns the pending event messages, oldest first.
+func (p *pending) drain() []message.Message {
+	msgs := make([]message.Message, 0, p.order.Len())
+	for el := p.order.Front(); el != nil; el = el.Next() {
+		msgs = append(msgs, el.Value.(*pendingEntry).msg)
+	}
+	p.entries = make(map[string]*list.Element)
+	p.order.Init()
+	return msgs
+}
+
+// take removes and returns the event message sent for the event ID.
+func (p *pending) take(id string) (message.Message, bool) {
+	el, ok := p.entries[id]
+	if !ok {
+		return nil, false
+	}
+	p.order.Remove(el)
+	delete(p.entries, id)
+	return el.Value.(*pendingEntry).msg, true
+}
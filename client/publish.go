@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/message"
+	"github.com/go-nostr/nostr/message/eventmessage"
+	"github.com/go-nostr/nostr/message/okmessage"
+)
+
+// PublishStatus is the outcome of publishing an event to a relay.
+type PublishStatus int
+
+// PublishStatus values.
+const (
+	PublishAccepted PublishStatus = iota
+	PublishRejected
+	PublishTimedOut
+	PublishFailed
+)
+
+// String returns the name of the status.
+func (s PublishStatus) String() string {
+	switch s {
+	case PublishAccepted:
+		return "accepted"
+	case PublishRejected:
+		return "rejected"
+	case PublishTimedOut:
+		return "timed out"
+	case PublishFailed:
+		return "failed"
+	}
+	return fmt.Sprintf("PublishStatus(%d)", int(s))
+}
+
+// PublishResult is the delivery report of an event published to a relay.
+// Prefix and Message are the parsed reason of the OK message of the relay,
+// Err is the error that prevented sending the event or waiting for the OK.
+type PublishResult struct {
+	Relay   string
+	Status  PublishStatus
+	Prefix  string
+	Message string
+	Err     error
+}
+
+// String returns a one-line report of the result.
+func (res *PublishResult) String() string {
+	switch {
+	case res.Err != nil:
+		return fmt.Sprintf("%s: %s: %v", res.Relay, res.Status, res.Err)
+	case res.Prefix != "":
+		return fmt.Sprintf("%s: %s: %s: %s", res.Relay, res.Status, res.Prefix, res.Message)
+	case res.Message != "":
+		return fmt.Sprintf("%s: %s: %s", res.Relay, res.Status, res.Message)
+	}
+	return fmt.Sprintf("%s: %s", res.Relay, res.Status)
+}
+
+// ack identifies the OK message of an event expected from a relay.
+type ack struct {
+	id    string
+	relay *Relay
+}
+
+// Publish sends the event to the relays at the given URLs, or to every relay
+// of the pool that is not closed when there are none, and waits for the OK
+// message of each relay until PublishTimeout or the context is done. It
+// returns the results in the order of the relays.
+func (cl *Client) Publish(ctx context.Context, evt *event.Event, urls ...string) []*PublishResult {
+	var relays []*Relay
+	var results []*PublishResult
+	if len(urls) == 0 {
+		relays, _ = cl.resolve(nil)
+		results = make([]*PublishResult, len(relays))
+	} else {
+		relays = make([]*Relay, len(urls))
+		results = make([]*PublishResult, len(urls))
+		for i, u := range urls {
+			if relays[i] = cl.Relay(u); relays[i] == nil {
+				results[i] = &PublishResult{Relay: u, Status: PublishFailed, Err: fmt.Errorf("%w: %s", ErrNotConnected, u)}
+			}
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, cl.PublishTimeout)
+	defer cancel()
+	msg, _ := eventmessage.New("", evt) // an empty subscription id is always valid
+	var wg sync.WaitGroup
+	for i, r := range relays {
+		if r == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, r *Relay) {
+			defer wg.Done()
+			results[i] = cl.publish(ctx, r, evt.ID, msg)
+		}(i, r)
+	}
+	wg.Wait()
+	return results
+}
+
+// publish sends the event message to the relay and waits for its OK message.
+func (cl *Client) publish(ctx context.Context, r *Relay, id string, msg message.Message) *PublishResult {
+	res := &PublishResult{Relay: r.url}
+	key := ack{id: id, relay: r}
+	okCh := make(chan *message.OkEnvelope, 1)
+	cl.mu.Lock()
+	cl.acks[key] = okCh
+	cl.mu.Unlock()
+	defer func() {
+		cl.mu.Lock()
+		delete(cl.acks, key)
+		cl.mu.Unlock()
+	}()
+	if err := r.Send(ctx, msg); err != nil {
+		res.Status, res.Err = PublishFailed, err
+		return res
+	}
+	select {
+	case env := <-okCh:
+		res.Prefix, res.Message = okmessage.ParseReason(env.Reason)
+		res.Status = PublishRejected
+		if env.OK {
+			res.Status = PublishAccepted
+		}
+	case <-ctx.Done():
+		res.Status, res.Err = PublishTimedOut, ctx.Err()
+	}
+	return res
+}
+
+// acknowledge delivers the OK message received from the relay to the
+// publication waiting for it, and reports whether there was one.
+func (cl *Client) acknowledge(r *Relay, env *message.OkEnvelope) bool {
+	cl.mu.Lock()
+	okCh, ok := cl.acks[ack{id: env.EventID, relay: r}]
+	cl.mu.Unlock()
+	if !ok {
+		return false
+	}
+	select {
+	case okCh <- env:
+	default:
+	}
+	return true
+}
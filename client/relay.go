@@ -0,0 +1,325 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-nostr/nostr/message"
+	"nhooyr.io/websocket"
+)
+
+// ErrNotConnected is returned when sending to a relay the client is not
+// connected to.
+var ErrNotConnected = errors.New("relay is not connected")
+
+// ErrQueueFull is returned when sending an event to a relay that is waiting to
+// reconnect and already queued QueueSize events.
+var ErrQueueFull = errors.New("relay queue is full")
+
+// Status is the state of the connection to a relay.
+type Status int
+
+// Status values of a relay connection.
+const (
+	StatusConnecting Status = iota
+	StatusConnected
+	StatusBackoff
+	StatusClosed
+)
+
+// String returns the name of the status.
+func (s Status) String() string {
+	switch s {
+	case StatusConnecting:
+		return "connecting"
+	case StatusConnected:
+		return "connected"
+	case StatusBackoff:
+		return "backoff"
+	case StatusClosed:
+		return "closed"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// NormalizeURL returns the canonical form of a relay URL, used as its key in
+// the pool: the scheme and host are lowercased, http and https are mapped to
+// ws and wss, default ports and trailing slashes are removed.
+func NormalizeURL(u string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(u))
+	if err != nil {
+		return "", err
+	}
+	switch scheme := strings.ToLower(parsed.Scheme); scheme {
+	case "ws", "http":
+		parsed.Scheme = "ws"
+	case "wss", "https":
+		parsed.Scheme = "wss"
+	default:
+		return "", fmt.Errorf("invalid relay url %q: unsupported scheme %q", u, parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return "", fmt.Errorf("invalid relay url %q: missing host", u)
+	}
+	host, port := strings.ToLower(parsed.Hostname()), parsed.Port()
+	if (parsed.Scheme == "ws" && port == "80") || (parsed.Scheme == "wss" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	parsed.Host = host
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	parsed.RawPath = ""
+	parsed.Fragment = ""
+	return parsed.String(), nil
+}
+
+// Relay is a relay of the client's pool. It holds the state of the connection
+// and remembers the events and subscriptions sent to the relay so they can be
+// sent again once the client has authenticated or reconnected. Events are
+// remembered until the relay acknowledges them or newer events push them out,
+// and those not acknowledged when the connection drops are sent again on the
+// next one.
+type Relay struct {
+	authID    string
+	authed    bool
+	challenge string
+	done      chan struct{}
+	events    *pending
+	queue     []message.Message
+	queueSize int
+	retries   []message.Message
+	since     map[string]int
+	status    Status
+	subs      map[string]message.Message
+	url       string
+	ws        *websocket.Conn
+	mu        sync.Mutex
+}
+
+// newRelay creates the relay at the normalized url.
+func newRelay(url string, queueSize int) *Relay {
+	return &Relay{
+		done:      make(chan struct{}),
+		events:    newPending(maxPendingEvents),
+		queueSize: queueSize,
+		since:     make(map[string]int),
+		status:    StatusConnecting,
+		subs:      make(map[string]message.Message),
+		url:       url,
+	}
+}
+
+// Send sends the message to the relay. While the relay is connecting or
+// waiting to reconnect, events are queued and subscriptions are recorded to
+// be sent once it is connected. Events sent when the queue is full are
+// dropped and ErrQueueFull is returned.
+func (r *Relay) Send(ctx context.Context, msg message.Message) error {
+	data, err := msg.Marshal()
+	if err != nil {
+		return err
+	}
+	return r.send(ctx, msg, data)
+}
+
+// Status returns the state of the connection to the relay.
+func (r *Relay) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// Subscriptions returns the IDs of the subscriptions open on the relay.
+func (r *Relay) Subscriptions() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]string, 0, len(r.subs))
+	for id := range r.subs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// URL returns the normalized URL of the relay.
+func (r *Relay) URL() string {
+	return r.url
+}
+
+// close closes the connection to the relay, which is not reconnected.
+func (r *Relay) close() error {
+	r.mu.Lock()
+	ws := r.ws
+	r.ws = nil
+	if r.status != StatusClosed {
+		r.status = StatusClosed
+		close(r.done)
+	}
+	r.mu.Unlock()
+	if ws == nil {
+		return nil
+	}
+	return ws.Close(websocket.StatusNormalClosure, "closing connection")
+}
+
+// connect marks the relay as connecting and reports whether it was closed, in
+// which case it must be dialed.
+func (r *Relay) connect() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status != StatusClosed {
+		return false
+	}
+	r.done = make(chan struct{})
+	r.status = StatusConnecting
+	return true
+}
+
+// connected records the websocket connection to the relay and returns the
+// messages to send on it: the open subscriptions, with since advanced to the
+// last event received, and the events still awaiting an OK message followed
+// by the queued ones. It returns false when the relay was closed while
+// connecting.
+func (r *Relay) connected(ws *websocket.Conn) ([]message.Message, []message.Message, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status == StatusClosed {
+		return nil, nil, false
+	}
+	r.ws = ws
+	r.status = StatusConnected
+	r.authed = false
+	r.authID = ""
+	subs := make([]message.Message, 0, len(r.subs))
+	for id, msg := range r.subs {
+		subs = append(subs, r.resume(id, msg))
+	}
+	// OK messages answering events sent on a previous connection never
+	// arrive, so the events are sent again before the queued ones.
+	queue := append(r.events.drain(), r.queue...)
+	r.queue = nil
+	return subs, queue, true
+}
+
+// disconnected marks the relay as waiting to reconnect, or as closed when it
+// must not reconnect, if ws is still its connection, and drops the messages
+// waiting for authentication on it. It reports whether it was.
+func (r *Relay) disconnected(ws *websocket.Conn, reconnect bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ws != ws {
+		return false
+	}
+	r.ws = nil
+	r.retries = nil
+	if !reconnect {
+		r.status = StatusClosed
+		close(r.done)
+		return true
+	}
+	r.status = StatusBackoff
+	return true
+}
+
+// isConn reports whether ws is the connection to the relay.
+func (r *Relay) isConn(ws *websocket.Conn) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ws == ws
+}
+
+// received records the creation time of the newest event received for each
+// subscription, from which subscriptions resume after reconnecting.
+func (r *Relay) received(env message.Envelope) {
+	evt, ok := env.(*message.EventEnvelope)
+	if !ok || evt.Event == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.subs[evt.SubscriptionID]; ok && evt.Event.CreatedAt > r.since[evt.SubscriptionID] {
+		r.since[evt.SubscriptionID] = evt.Event.CreatedAt
+	}
+}
+
+// resume returns the subscription message with since advanced to the last
+// event received for it. The relay must be locked.
+func (r *Relay) resume(id string, msg message.Message) message.Message {
+	since, ok := r.since[id]
+	if !ok {
+		return msg
+	}
+	env, err := message.ParseMessage(msg)
+	if err != nil {
+		return msg
+	}
+	req, ok := env.(*message.ReqEnvelope)
+	if !ok {
+		return msg
+	}
+	for i, f := range req.Filters {
+		if f.Since < since {
+			cp := *f
+			cp.Since = since
+			req.Filters[i] = &cp
+		}
+	}
+	return req.Message()
+}
+
+// transition changes the status of the relay from one status to another, and
+// reports whether the relay had the expected status.
+func (r *Relay) transition(from Status, to Status) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status != from {
+		return false
+	}
+	r.status = to
+	return true
+}
+
+// send writes the message to the relay, remembering the events and
+// subscriptions it sends.
+func (r *Relay) send(ctx context.Context, msg message.Message, data []byte) error {
+	r.mu.Lock()
+	ws := r.ws
+	if ws == nil && r.status == StatusClosed {
+		r.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrNotConnected, r.url)
+	}
+	if env, err := message.ParseMessage(msg); err == nil {
+		switch env := env.(type) {
+		case *message.EventEnvelope:
+			if ws == nil {
+				if len(r.queue) >= r.queueSize {
+					r.mu.Unlock()
+					return fmt.Errorf("%w: %s", ErrQueueFull, r.url)
+				}
+				r.queue = append(r.queue, msg)
+			} else {
+				r.events.add(env.Event.ID, msg)
+			}
+		case *message.ReqEnvelope:
+			r.subs[env.SubscriptionID] = msg
+			delete(r.since, env.SubscriptionID)
+		case *message.CloseEnvelope:
+			delete(r.subs, env.SubscriptionID)
+			delete(r.since, env.SubscriptionID)
+		}
+	}
+	r.mu.Unlock()
+	if ws == nil {
+		return nil
+	}
+	return ws.Write(ctx, websocket.MessageText, data)
+}
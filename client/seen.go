@@ -0,0 +1,82 @@
+package client
+
+import (
+	"container/list"
+	"sync"
+)
+
+// seen is a bounded LRU of the events received from relays, keyed by event
+// ID. It records the relays each event was seen on and the subscriptions it
+// was delivered to.
+type seen struct {
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+	mu       sync.Mutex
+}
+
+// seenEntry is an event of the seen LRU.
+type seenEntry struct {
+	id     string
+	relays []string
+	subs   map[string]bool
+}
+
+// newSeen creates a seen LRU holding up to capacity events.
+func newSeen(capacity int) *seen {
+	return &seen{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// record records that the event was received from the relay for the
+// subscription, and reports whether it is the first copy received for the
+// subscription.
+func (s *seen) record(id string, url string, subscriptionID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.entries[id]
+	if !ok {
+		el = s.order.PushFront(&seenEntry{id: id, subs: make(map[string]bool)})
+		s.entries[id] = el
+		if s.order.Len() > s.capacity {
+			oldest := s.order.Back()
+			s.order.Remove(oldest)
+			delete(s.entries, oldest.Value.(*seenEntry).id)
+		}
+	} else {
+		s.order.MoveToFront(el)
+	}
+	e := el.Value.(*seenEntry)
+	if !containsString(e.relays, url) {
+		e.relays = append(e.relays, url)
+	}
+	if e.subs[subscriptionID] {
+		return false
+	}
+	e.subs[subscriptionID] = true
+	return true
+}
+
+// relays returns the URLs of the relays the event was seen on.
+func (s *seen) relays(id string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.entries[id]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), el.Value.(*seenEntry).relays...)
+}
+
+// containsString reports whether the string is in the list.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,296 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/message"
+	"github.com/go-nostr/nostr/message/closemessage"
+	"github.com/go-nostr/nostr/message/okmessage"
+	"github.com/go-nostr/nostr/message/requestmessage"
+	"github.com/go-nostr/nostr/subscriptionid"
+)
+
+// SubscribeOptions configures a subscription. The zero value subscribes to
+// every relay of the pool with a random subscription ID.
+type SubscribeOptions struct {
+	ID         string   // ID is the subscription ID, generated when empty.
+	Relays     []string // Relays are the URLs of the relays to subscribe to, all relays of the pool when empty.
+	BufferSize int      // BufferSize is the capacity of the Events channel, the BufferSize of the client when zero.
+	Overflow   Overflow // Overflow is the policy applied when the Events channel is full.
+}
+
+// Subscription is a subscription to events of one or more relays. Events
+// received for the subscription are sent on Events, which is closed once the
+// subscription is closed. EOSE is closed once every relay sent the stored
+// events, and Closed is closed when the subscription is unsubscribed or every
+// relay closed it.
+type Subscription struct {
+	ID      string
+	Filters message.Filters
+	Events  <-chan *event.Event
+	EOSE    <-chan struct{}
+	Closed  <-chan struct{}
+
+	client   *Client
+	closed   chan struct{}
+	eose     chan struct{}
+	eoseOnce sync.Once
+	events   chan *event.Event
+	open     map[*Relay]bool
+	overflow Overflow
+	pending  map[*Relay]bool
+	reason   string
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+}
+
+// Subscribe sends a REQ message with the filters to the relays of the options
+// and returns the subscription receiving the matching events. Messages of the
+// subscription are routed to it by subscription ID instead of the message
+// handler. The subscription is unsubscribed when the context is done.
+func (cl *Client) Subscribe(ctx context.Context, filters message.Filters, opts *SubscribeOptions) (*Subscription, error) {
+	if opts == nil {
+		opts = &SubscribeOptions{}
+	}
+	id := opts.ID
+	if id == "" {
+		id = subscriptionid.New()
+	}
+	if err := subscriptionid.Validate(id); err != nil {
+		return nil, err
+	}
+	size := opts.BufferSize
+	if size <= 0 {
+		size = cl.BufferSize
+	}
+	relays, err := cl.resolve(opts.Relays)
+	if err != nil {
+		return nil, err
+	}
+	if len(relays) == 0 {
+		return nil, fmt.Errorf("%w: no relays to subscribe to", ErrNotConnected)
+	}
+	s := &Subscription{
+		ID:       id,
+		Filters:  filters,
+		client:   cl,
+		closed:   make(chan struct{}),
+		eose:     make(chan struct{}),
+		events:   make(chan *event.Event, size),
+		open:     make(map[*Relay]bool, len(relays)),
+		overflow: opts.Overflow,
+		pending:  make(map[*Relay]bool, len(relays)),
+	}
+	s.Events, s.EOSE, s.Closed = s.events, s.eose, s.closed
+	for _, r := range relays {
+		s.open[r] = true
+		s.pending[r] = true
+	}
+	cl.mu.Lock()
+	if _, ok := cl.subs[id]; ok {
+		cl.mu.Unlock()
+		return nil, fmt.Errorf("subscription %s already exists", id)
+	}
+	cl.subs[id] = s
+	cl.mu.Unlock()
+	msg := requestmessage.New(id, filters...)
+	var errs []error
+	for _, r := range relays {
+		if err := r.Send(ctx, msg); err != nil {
+			errs = append(errs, err)
+			s.closedBy(r, err.Error())
+		}
+	}
+	if len(errs) == len(relays) {
+		return nil, errors.Join(errs...)
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Unsubscribe()
+		case <-s.closed:
+		}
+	}()
+	return s, nil
+}
+
+// Reason returns the reason of the last relay that closed the subscription,
+// or an empty string if it was unsubscribed.
+func (s *Subscription) Reason() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reason
+}
+
+// Unsubscribe sends a CLOSE message to the relays where the subscription is
+// open and closes the subscription.
+func (s *Subscription) Unsubscribe() error {
+	return s.close("")
+}
+
+// close sends a CLOSE message to the relays where the subscription is open and
+// closes the subscription with the reason.
+func (s *Subscription) close(reason string) error {
+	s.mu.Lock()
+	relays := make([]*Relay, 0, len(s.open))
+	for r := range s.open {
+		relays = append(relays, r)
+	}
+	s.mu.Unlock()
+	var errs []error
+	for _, r := range relays {
+		if err := r.Send(context.Background(), closemessage.New(s.ID)); err != nil && !errors.Is(err, ErrNotConnected) {
+			errs = append(errs, err)
+		}
+	}
+	s.finish(reason)
+	return errors.Join(errs...)
+}
+
+// closedBy records that the relay closed the subscription for the reason, and
+// closes the subscription when no relay has it open.
+func (s *Subscription) closedBy(r *Relay, reason string) {
+	s.mu.Lock()
+	delete(s.open, r)
+	remaining := len(s.open)
+	s.mu.Unlock()
+	s.endOfStoredEvents(r)
+	if remaining == 0 {
+		s.finish(reason)
+	}
+}
+
+// deliver sends the event on the Events channel, applying the Overflow policy
+// when it is full.
+func (s *Subscription) deliver(evt *event.Event) {
+	s.mu.Lock()
+	select {
+	case <-s.closed:
+		s.mu.Unlock()
+		return
+	default:
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+	defer s.wg.Done()
+	switch s.overflow {
+	case OverflowBlock:
+		select {
+		case s.events <- evt:
+		case <-s.closed:
+		}
+	case OverflowDisconnect:
+		if !tryPush(s.events, evt) {
+			s.close(okmessage.Reason(okmessage.PrefixError, ErrOverflow.Error()))
+		}
+	default:
+		dropOldest(s.events, evt)
+	}
+}
+
+// endOfStoredEvents records that the relay sent the stored events, and closes
+// EOSE when every relay did.
+func (s *Subscription) endOfStoredEvents(r *Relay) {
+	s.mu.Lock()
+	delete(s.pending, r)
+	remaining := len(s.pending)
+	s.mu.Unlock()
+	if remaining == 0 {
+		s.eoseOnce.Do(func() { close(s.eose) })
+	}
+}
+
+// finish closes the subscription with the reason, once, and removes it from
+// the client. Events is closed once pending deliveries returned.
+func (s *Subscription) finish(reason string) {
+	s.mu.Lock()
+	select {
+	case <-s.closed:
+		s.mu.Unlock()
+		return
+	default:
+	}
+	s.reason = reason
+	s.open = map[*Relay]bool{}
+	close(s.closed)
+	s.mu.Unlock()
+	s.client.mu.Lock()
+	if s.client.subs[s.ID] == s {
+		delete(s.client.subs, s.ID)
+	}
+	s.client.mu.Unlock()
+	go func() {
+		s.wg.Wait()
+		close(s.events)
+	}()
+}
+
+// route delivers a message received from the relay to the subscription or
+// publication it belongs to, and reports whether it did. OK and CLOSED
+// messages of events and subscriptions that will be sent again after
+// authenticating are not delivered.
+func (cl *Client) route(r *Relay, env message.Envelope, retry bool) bool {
+	var id string
+	switch env := env.(type) {
+	case *message.OkEnvelope:
+		if retry {
+			return false
+		}
+		return cl.acknowledge(r, env)
+	case *message.EventEnvelope:
+		id = env.SubscriptionID
+	case *message.EoseEnvelope:
+		id = env.SubscriptionID
+	case *message.ClosedEnvelope:
+		id = env.SubscriptionID
+	}
+	if id == "" {
+		return false
+	}
+	cl.mu.Lock()
+	s := cl.subs[id]
+	cl.mu.Unlock()
+	if s == nil {
+		return false
+	}
+	switch env := env.(type) {
+	case *message.EventEnvelope:
+		if env.Event != nil {
+			s.deliver(env.Event)
+		}
+	case *message.EoseEnvelope:
+		s.endOfStoredEvents(r)
+	case *message.ClosedEnvelope:
+		if !retry {
+			s.closedBy(r, env.Reason)
+		}
+	}
+	return true
+}
+
+// resolve returns the relays of the pool at the URLs, or every relay of the
+// pool that is not closed when there are none.
+func (cl *Client) resolve(urls []string) ([]*Relay, error) {
+	if len(urls) == 0 {
+		var relays []*Relay
+		for _, r := range cl.Relays() {
+			if r.Status() != StatusClosed {
+				relays = append(relays, r)
+			}
+		}
+		return relays, nil
+	}
+	relays := make([]*Relay, 0, len(urls))
+	for _, u := range urls {
+		r := cl.Relay(u)
+		if r == nil {
+			return nil, fmt.Errorf("%w: %s", ErrNotConnected, u)
+		}
+		relays = append(relays, r)
+	}
+	return relays, nil
+}
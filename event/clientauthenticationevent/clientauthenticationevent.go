@@ -1,13 +1,71 @@
 package clientauthenticationevent
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
 	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/tag/challengetag"
+	"github.com/go-nostr/nostr/tag/relaytag"
 )
 
 // Event for client authentication process
 const Kind = 22242
 
-// New creates a new ClientAuthenticationEvent.
-func New() *event.Event {
-	return event.New(Kind, "")
+// MaxAge is the maximum difference between the creation time of an event and
+// the time it is checked.
+const MaxAge = 10 * time.Minute
+
+// New creates a new ClientAuthenticationEvent answering the challenge of the
+// relay at relayURL.
+func New(relayURL string, challenge string) *event.Event {
+	return event.New(Kind, "", relaytag.New(relayURL), challengetag.New(challenge))
+}
+
+// Check checks that the event is a valid authentication to the relay at
+// relayURL for the challenge: its kind, relay and challenge tags, creation
+// time and signature.
+func Check(evt *event.Event, relayURL string, challenge string) error {
+	if evt.Kind != Kind {
+		return fmt.Errorf("expected kind %d, got %d", Kind, evt.Kind)
+	}
+	if got := value(evt, challengetag.Type); got != challenge {
+		return fmt.Errorf("challenge %q does not match", got)
+	}
+	if got := value(evt, relaytag.Type); !sameRelay(got, relayURL) {
+		return fmt.Errorf("relay %q does not match %q", got, relayURL)
+	}
+	createdAt := time.Unix(int64(evt.CreatedAt), 0)
+	if d := time.Since(createdAt); d > MaxAge || d < -MaxAge {
+		return fmt.Errorf("created_at is more than %v away from now", MaxAge)
+	}
+	return evt.Verify()
+}
+
+// sameRelay reports whether both URLs point to the same relay, ignoring the
+// scheme, letter case of the host and trailing slashes.
+func sameRelay(a string, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil || ua.Host == "" {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Host, ub.Host) && strings.TrimSuffix(ua.Path, "/") == strings.TrimSuffix(ub.Path, "/")
+}
+
+// value returns the value of the first tag of the event with the given name.
+func value(evt *event.Event, name string) string {
+	for _, t := range evt.Tags {
+		if len(t) > 1 && t[0] == name {
+			if v, ok := t[1].(string); ok {
+				return v
+			}
+		}
+	}
+	return ""
 }
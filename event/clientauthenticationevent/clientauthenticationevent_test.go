@@ -0,0 +1,91 @@
+package clientauthenticationevent_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/event/clientauthenticationevent"
+)
+
+func TestCheck(t *testing.T) {
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(evt *event.Event) *event.Event {
+		if err := evt.SignWith(context.TODO(), signer); err != nil {
+			t.Fatal(err)
+		}
+		return evt
+	}
+	tests := []struct {
+		name      string
+		evt       *event.Event
+		relayURL  string
+		challenge string
+		expectErr bool
+	}{
+		{
+			name:      "SHOULD accept valid authentication",
+			evt:       sign(clientauthenticationevent.New("wss://relay.example.com/", "abc")),
+			relayURL:  "wss://Relay.Example.com",
+			challenge: "abc",
+			expectErr: false,
+		},
+		{
+			name:      "SHOULD reject wrong challenge",
+			evt:       sign(clientauthenticationevent.New("wss://relay.example.com", "abc")),
+			relayURL:  "wss://relay.example.com",
+			challenge: "def",
+			expectErr: true,
+		},
+		{
+			name:      "SHOULD reject wrong relay",
+			evt:       sign(clientauthenticationevent.New("wss://other.example.com", "abc")),
+			relayURL:  "wss://relay.example.com",
+			challenge: "abc",
+			expectErr: true,
+		},
+		{
+			name:      "SHOULD reject wrong kind",
+			evt:       sign(event.New(1, "", clientauthenticationevent.New("wss://relay.example.com", "abc").Tags...)),
+			relayURL:  "wss://relay.example.com",
+			challenge: "abc",
+			expectErr: true,
+		},
+		{
+			name: "SHOULD reject old event",
+			evt: sign((func() *event.Event {
+				evt := clientauthenticationevent.New("wss://relay.example.com", "abc")
+				evt.CreatedAt = int(time.Now().Add(-time.Hour).Unix())
+				return evt
+			})()),
+			relayURL:  "wss://relay.example.com",
+			challenge: "abc",
+			expectErr: true,
+		},
+		{
+			name: "SHOULD reject invalid signature",
+			evt: (func() *event.Event {
+				evt := sign(clientauthenticationevent.New("wss://relay.example.com", "abc"))
+				evt.Sig = strings.Repeat("0", len(evt.Sig))
+				return evt
+			})(),
+			relayURL:  "wss://relay.example.com",
+			challenge: "abc",
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := clientauthenticationevent.Check(tt.evt, tt.relayURL, tt.challenge)
+			t.Logf("got %v", err)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("expected error %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
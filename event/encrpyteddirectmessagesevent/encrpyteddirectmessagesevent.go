@@ -1,122 +1,68 @@
 package encrpyteddirectmessagesevent
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/keys"
+	"github.com/go-nostr/nostr/nip04"
+	"github.com/go-nostr/nostr/tag"
 )
 
 // Kind for encrypted direct messages
 const Kind = 4
 
-// NewEncryptedDirectMessagesEvent creates a new encrypted direct messages
-// event. For more information, visit:
+// ErrNoRecipient is returned when decrypting a direct message without a "p"
+// tag.
+var ErrNoRecipient = errors.New("direct message has no recipient")
+
+// New creates an encrypted direct message from the hex encoded private key to
+// the hex encoded public key of the recipient, tagged with a "p" tag and
+// signed. For more information, visit:
 // https://github.com/nostr-protocol/nips/blob/master/04.md
-func New(message string) *event.Event {
-	initializationVector := ""
-	encryptedMessage := ""
-	content := fmt.Sprintf("%s?iv=%s", initializationVector, encryptedMessage)
-	return event.New(Kind, content)
+func New(prvKeyHex string, pubKeyHex string, message string) (*event.Event, error) {
+	content, err := nip04.Encrypt(prvKeyHex, pubKeyHex, message)
+	if err != nil {
+		return nil, err
+	}
+	evt := event.New(Kind, content, tag.New("p", pubKeyHex))
+	if err := evt.Sign(prvKeyHex); err != nil {
+		return nil, err
+	}
+	return evt, nil
 }
 
-// newAEAD := func(key []byte) (cipher.AEAD, error) {
-// 	block, err := aes.NewCipher(key)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	return cipher.NewGCM(block)
-// }
-
-// // Decode the hex-encoded pubkey of the recipient.
-// pubKeyBytes, err := hex.DecodeString("04115c42e757b2efb7671c578530ec191a1" +
-// 	"359381e6a71127a9d37c486fd30dae57e76dc58f693bd7e7010358ce6b165e483a29" +
-// 	"21010db67ac11b1b51b651953d2") // uncompressed pubkey
-// if err != nil {
-// 	fmt.Println(err)
-// 	return
-// }
-// pubKey, err := secp256k1.ParsePubKey(pubKeyBytes)
-// if err != nil {
-// 	fmt.Println(err)
-// 	return
-// }
-
-// // Derive an ephemeral public/private keypair for performing ECDHE with
-// // the recipient.
-// ephemeralPrivKey, err := secp256k1.GeneratePrivateKey()
-// if err != nil {
-// 	fmt.Println(err)
-// 	return
-// }
-// ephemeralPubKey := ephemeralPrivKey.PubKey().SerializeCompressed()
-
-// // Using ECDHE, derive a shared symmetric key for encryption of the plaintext.
-// cipherKey := sha256.Sum256(secp256k1.GenerateSharedSecret(ephemeralPrivKey, pubKey))
-
-// // Seal the message using an AEAD.  Here we use AES-256-GCM.
-// // The ephemeral public key must be included in this message, and becomes
-// // the authenticated data for the AEAD.
-// //
-// // Note that unless a unique nonce can be guaranteed, the ephemeral
-// // and/or shared keys must not be reused to encrypt different messages.
-// // Doing so destroys the security of the scheme.  Random nonces may be
-// // used if XChaCha20-Poly1305 is used instead, but the message must then
-// // also encode the nonce (which we don't do here).
-// //
-// // Since a new ephemeral key is generated for every message ensuring there
-// // is no key reuse and AES-GCM permits the nonce to be used as a counter,
-// // the nonce is intentionally initialized to all zeros so it acts like the
-// // first (and only) use of a counter.
-// plaintext := []byte("test message")
-// aead, err := newAEAD(cipherKey[:])
-// if err != nil {
-// 	fmt.Println(err)
-// 	return
-// }
-// nonce := make([]byte, aead.NonceSize())
-// ciphertext := make([]byte, 4+len(ephemeralPubKey))
-// binary.LittleEndian.PutUint32(ciphertext, uint32(len(ephemeralPubKey)))
-// copy(ciphertext[4:], ephemeralPubKey)
-// ciphertext = aead.Seal(ciphertext, nonce, plaintext, ephemeralPubKey)
-
-// // The remainder of this example is performed by the recipient on the
-// // ciphertext shared by the sender.
-
-// // Decode the hex-encoded private key.
-// pkBytes, err := hex.DecodeString("a11b0a4e1a132305652ee7a8eb7848f6ad" +
-// 	"5ea381e3ce20a2c086a2e388230811")
-// if err != nil {
-// 	fmt.Println(err)
-// 	return
-// }
-// privKey := secp256k1.PrivKeyFromBytes(pkBytes)
-
-// // Read the sender's ephemeral public key from the start of the message.
-// // Error handling for inappropriate pubkey lengths is elided here for
-// // brevity.
-// pubKeyLen := binary.LittleEndian.Uint32(ciphertext[:4])
-// senderPubKeyBytes := ciphertext[4 : 4+pubKeyLen]
-// senderPubKey, err := secp256k1.ParsePubKey(senderPubKeyBytes)
-// if err != nil {
-// 	fmt.Println(err)
-// 	return
-// }
-
-// // Derive the key used to seal the message, this time from the
-// // recipient's private key and the sender's public key.
-// recoveredCipherKey := sha256.Sum256(secp256k1.GenerateSharedSecret(privKey, senderPubKey))
-
-// // Open the sealed message.
-// aead, err = newAEAD(recoveredCipherKey[:])
-// if err != nil {
-// 	fmt.Println(err)
-// 	return
-// }
-// nonce = make([]byte, aead.NonceSize())
-// recoveredPlaintext, err := aead.Open(nil, nonce, ciphertext[4+pubKeyLen:], senderPubKeyBytes)
-// if err != nil {
-// 	fmt.Println(err)
-// 	return
-// }
+// Decrypt decrypts an encrypted direct message with the hex encoded private
+// key of either its author or its recipient.
+func Decrypt(prvKeyHex string, evt *event.Event) (string, error) {
+	if evt.Kind != Kind {
+		return "", fmt.Errorf("unexpected event kind %d", evt.Kind)
+	}
+	prvKey, err := keys.ParsePrivateKey(prvKeyHex)
+	if err != nil {
+		return "", err
+	}
+	self := prvKey.PublicKey().Hex()
+	prvKey.Zero()
+	pubKeyHex := evt.PubKey
+	if self == evt.PubKey {
+		if pubKeyHex = recipient(evt); pubKeyHex == "" {
+			return "", ErrNoRecipient
+		}
+	}
+	return nip04.Decrypt(prvKeyHex, pubKeyHex, evt.Content)
+}
 
-// fmt.Println(string(recoveredPlaintext))
+// recipient returns the public key of the first "p" tag of the event.
+func recipient(evt *event.Event) string {
+	for _, t := range evt.Tags {
+		if len(t) < 2 || t[0] != "p" {
+			continue
+		}
+		if pubKeyHex, ok := t[1].(string); ok {
+			return pubKeyHex
+		}
+	}
+	return ""
+}
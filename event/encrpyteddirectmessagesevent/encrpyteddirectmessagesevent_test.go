@@ -0,0 +1,67 @@
+package encrpyteddirectmessagesevent_test
+
+import (
+	"testing"
+
+	"github.com/go-nostr/nostr/event/encrpyteddirectmessagesevent"
+)
+
+const (
+	prvKey1 = "0000000000000000000000000000000000000000000000000000000000000001"
+	prvKey2 = "0000000000000000000000000000000000000000000000000000000000000002"
+	prvKey3 = "0000000000000000000000000000000000000000000000000000000000000003"
+	pubKey2 = "c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"
+)
+
+func TestDecrypt(t *testing.T) {
+	evt, err := encrpyteddirectmessagesevent.New(prvKey1, pubKey2, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := evt.Verify(); err != nil {
+		t.Fatalf("expected signed event, got %v", err)
+	}
+	if len(evt.Tags) != 1 || evt.Tags[0][0] != "p" || evt.Tags[0][1] != pubKey2 {
+		t.Fatalf("expected p tag %v, got %v", pubKey2, evt.Tags)
+	}
+	tests := []struct {
+		name      string
+		prvKeyHex string
+		expect    string
+	}{
+		{
+			name:      "SHOULD decrypt as recipient",
+			prvKeyHex: prvKey2,
+			expect:    "hello",
+		},
+		{
+			name:      "SHOULD decrypt as author",
+			prvKeyHex: prvKey1,
+			expect:    "hello",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := encrpyteddirectmessagesevent.Decrypt(tt.prvKeyHex, evt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.expect != got {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+			t.Logf("got %v", got)
+		})
+	}
+}
+
+func TestDecrypt_ThirdParty(t *testing.T) {
+	evt, err := encrpyteddirectmessagesevent.New(prvKey1, pubKey2, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a wrong key fails on the padding most of the time, but may also yield
+	// garbage.
+	if got, err := encrpyteddirectmessagesevent.Decrypt(prvKey3, evt); err == nil && got == "hello" {
+		t.Errorf("expected third party to fail, got %v", got)
+	}
+}
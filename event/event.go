@@ -1,13 +1,15 @@
 package event
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
-	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/go-nostr/nostr/tag"
 )
@@ -23,13 +25,23 @@ func New(kind int, content string, tag ...tag.Tag) *Event {
 
 // Event is a representation of an event
 type Event struct {
-	Content   string    `json:"content,omitempty"`
+	Content   string    `json:"content"`
 	CreatedAt int       `json:"created_at,omitempty"`
 	ID        string    `json:"id,omitempty"`
-	Kind      int       `json:"kind,omitempty"`
+	Kind      int       `json:"kind"`
 	PubKey    string    `json:"pubkey,omitempty"`
 	Sig       string    `json:"sig,omitempty"`
-	Tags      []tag.Tag `json:"tags,omitempty"`
+	Tags      []tag.Tag `json:"tags"`
+}
+
+// MarshalJSON marshals the event with an empty tags array when it has no
+// tags, since NIP-01 requires the field.
+func (e Event) MarshalJSON() ([]byte, error) {
+	type event Event
+	if e.Tags == nil {
+		e.Tags = []tag.Tag{}
+	}
+	return json.Marshal(event(e))
 }
 
 // Marshal marshals the Event to a byte slice.
@@ -37,34 +49,81 @@ func (e *Event) Marshal() ([]byte, error) {
 	return json.Marshal(*e)
 }
 
-// Serialize TBD
+// Serialize returns the canonical NIP-01 serialization of the event that is
+// hashed to produce its ID:
+//
+//	[0,<pubkey>,<created_at>,<kind>,<tags>,<content>]
+//
+// Strings are escaped using only the escape sequences allowed by NIP-01, so
+// the output is byte-for-byte identical to other Nostr implementations.
 func (e *Event) Serialize() []byte {
-	if e.Tags == nil {
-		e.Tags = make([]tag.Tag, 0)
+	var buf bytes.Buffer
+	buf.WriteString("[0,")
+	writeString(&buf, e.PubKey)
+	buf.WriteByte(',')
+	buf.WriteString(strconv.Itoa(e.CreatedAt))
+	buf.WriteByte(',')
+	buf.WriteString(strconv.Itoa(e.Kind))
+	buf.WriteString(",[")
+	for i, t := range e.Tags {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('[')
+		for j, v := range t {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			writeValue(&buf, v)
+		}
+		buf.WriteByte(']')
 	}
-	tagsData, _ := json.Marshal(e.Tags)
-	return []byte(fmt.Sprintf("[0,%s,%d,%d,%s,%s]", e.PubKey, e.CreatedAt, e.Kind, tagsData, e.Content))
+	buf.WriteString("],")
+	writeString(&buf, e.Content)
+	buf.WriteByte(']')
+	return buf.Bytes()
+}
+
+// ComputeID returns the hex encoded sha256 hash of the serialized event.
+func (e *Event) ComputeID() string {
+	hash := sha256.Sum256(e.Serialize())
+	return hex.EncodeToString(hash[:])
 }
 
-// Sign signs the event.
+// CheckID reports whether the event ID matches the hash of the serialized event.
+func (e *Event) CheckID() bool {
+	return e.ID == e.ComputeID()
+}
+
+// Sign signs the event with a hex encoded private key. It is a shorthand for
+// SignWith using a KeySigner.
 func (e *Event) Sign(prvKeyHex string) error {
-	prvKeyStr, err := hex.DecodeString(prvKeyHex)
+	signer, err := NewKeySigner(prvKeyHex)
 	if err != nil {
-		return nil
+		return err
+	}
+	return e.SignWith(context.Background(), signer)
+}
+
+// SignWith signs the event using the given Signer. CreatedAt is set to the
+// current time only when it has not been set by the caller. The signed event
+// is verified before returning so a misbehaving signer is reported as an error.
+func (e *Event) SignWith(ctx context.Context, signer Signer) error {
+	if signer == nil {
+		return fmt.Errorf("no signer provided")
+	}
+	if e.CreatedAt == 0 {
+		e.CreatedAt = int(time.Now().Unix())
 	}
-	prvKey, pubKey := btcec.PrivKeyFromBytes(prvKeyStr)
-	e.PubKey = hex.EncodeToString(pubKey.SerializeCompressed()[1:])
-	e.CreatedAt = int(time.Now().Unix())
 	if e.Tags == nil {
 		e.Tags = []tag.Tag{}
 	}
-	hash := sha256.Sum256(e.Serialize())
-	sig, err := schnorr.Sign(prvKey, hash[:])
-	if err != nil {
+	if err := signer.SignEvent(ctx, e); err != nil {
 		return err
 	}
-	e.ID = hex.EncodeToString(hash[:])
-	e.Sig = hex.EncodeToString(sig.Serialize())
+	if err := e.Verify(); err != nil {
+		return fmt.Errorf("signer returned invalid event: %w", err)
+	}
 	return nil
 }
 
@@ -73,11 +132,15 @@ func (e *Event) Unmarshal(data []byte) error {
 	return json.Unmarshal(data, e)
 }
 
-// Verify verifies the event pubkey and signature.
+// Verify verifies the event ID, pubkey and signature.
 func (e *Event) Verify() error {
+	hash := sha256.Sum256(e.Serialize())
+	if e.ID != hex.EncodeToString(hash[:]) {
+		return fmt.Errorf("invalid event id")
+	}
 	pubKeyStr, err := hex.DecodeString(e.PubKey)
 	if err != nil {
-		return nil
+		return err
 	}
 	pubKey, err := schnorr.ParsePubKey(pubKeyStr)
 	if err != nil {
@@ -87,16 +150,55 @@ func (e *Event) Verify() error {
 	if err != nil {
 		return err
 	}
-	if err != nil {
-		return err
-	}
 	sig, err := schnorr.ParseSignature(sigStr)
 	if err != nil {
 		return err
 	}
-	hsh := sha256.Sum256(e.Serialize())
-	if !sig.Verify(hsh[:], pubKey) {
+	if !sig.Verify(hash[:], pubKey) {
 		return fmt.Errorf("unable to verify")
 	}
 	return nil
 }
+
+// writeString writes s as a JSON string using the NIP-01 escape rules: only
+// line feed, double quote, backslash, carriage return, tab, backspace and
+// form feed are escaped, every other character is written verbatim.
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\n':
+			buf.WriteString(`\n`)
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// writeValue writes a single tag value. Tag values are expected to be strings,
+// anything else falls back to its JSON encoding.
+func writeValue(buf *bytes.Buffer, v any) {
+	if s, ok := v.(string); ok {
+		writeString(buf, s)
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		buf.WriteString("null")
+		return
+	}
+	buf.Write(data)
+}
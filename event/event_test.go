@@ -2,7 +2,9 @@ package event_test
 
 import (
 	"encoding/hex"
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/btcsuite/btcd/btcec/v2"
@@ -61,7 +63,7 @@ func TestEvent_Marshal(t *testing.T) {
 			fields: fields{
 				event: &event.Event{},
 			},
-			expect: []byte(`{}`),
+			expect: []byte(`{"content":"","kind":0,"tags":[]}`),
 			err:    nil,
 		},
 	}
@@ -93,7 +95,20 @@ func TestEvent_Serialize(t *testing.T) {
 			fields: fields{
 				event: event.New(1, "content"),
 			},
-			expect: []byte(`[0,,0,1,[],content]`),
+			expect: []byte(`[0,"",0,1,[],"content"]`),
+		},
+		{
+			name: "SHOULD escape strings per NIP-01",
+			fields: fields{
+				event: &event.Event{
+					PubKey:    "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+					CreatedAt: 1700000000,
+					Kind:      1,
+					Tags:      []tag.Tag{{"t", "<tag>&"}},
+					Content:   "a\nb\"c\\d\re\tf\bg\fh 🤙 \u2028",
+				},
+			},
+			expect: []byte("[0,\"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9\",1700000000,1,[[\"t\",\"<tag>&\"]],\"a\\nb\\\"c\\\\d\\re\\tf\\bg\\fh 🤙 \u2028\"]"),
 		},
 	}
 	for _, tt := range tests {
@@ -107,6 +122,73 @@ func TestEvent_Serialize(t *testing.T) {
 	}
 }
 
+// vectors are events signed and serialized by an independent implementation.
+var vectors = []string{
+	`{"id":"5020d406443aead0f7bcfbb435215dd67ecb0a1e611f8428b4a7d24f8df03ab1","pubkey":"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9","created_at":1672068534,"kind":1,"tags":[],"content":"hello world","sig":"7af707aeb8290507afdb1cbc6766b3a2696277c90e3a30a94e56bb5de951a4c91b50acb339e6b6473618d3a3563cb493e6f3b277e5283c8b7491a5da56cf561a"}`,
+	`{"id":"7e175192220e3ebbd337b1e6eef066f05e97a418f655d86b5815b2914333bad1","pubkey":"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9","created_at":1686199583,"kind":1,"tags":[["e","5c83da77af1dec6d7289834998ad7aafbd9e2191396d75ec3cc27f5a77226f36","wss://nostr.example.com"],["p","f7234bd4c1394dda46d09f35bd384dd30cc552ad5541990f98844fb06676e9ca"]],"content":"Line one\nLine \"two\"\twith tab and back\\slash","sig":"f69c703754f1d760203c3221b41b1642ebfecc3c8f1f67dd767fa809f94b49060aa78a12a39f3326a0c2309bc3637228f80109ce41eb579c0abdffbac28c6a4a"}`,
+	`{"id":"e3aad50fae97001ef5f245fa24914006791988c33896c03a937e1f10ff0bf910","pubkey":"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9","created_at":1700000000,"kind":0,"tags":[["t","<tag>&"]],"content":"{\"name\":\"nostr 🤙\",\"about\":\"<b>café</b> & more\r\n\"}","sig":"a2e7fcefbff7c543cb28e416d12b561cf9ee827bbe7e1763a1eaf1de16db68bc9232c557c5dc44193dc666387b6b96dd2bd524ce8b67462e50c14762bc17f302"}`,
+}
+
+func TestEvent_ComputeID(t *testing.T) {
+	for _, v := range vectors {
+		evt := new(event.Event)
+		if err := evt.Unmarshal([]byte(v)); err != nil {
+			t.Fatal(err)
+		}
+		t.Run("SHOULD compute ID "+evt.ID, func(t *testing.T) {
+			got := evt.ComputeID()
+			if got != evt.ID {
+				t.Errorf("expected %v, got %v", evt.ID, got)
+			}
+			if !evt.CheckID() {
+				t.Errorf("expected CheckID to succeed")
+			}
+			t.Logf("got %v", got)
+		})
+	}
+}
+
+func TestEvent_CheckID(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*event.Event)
+		expect bool
+	}{
+		{
+			name:   "SHOULD accept unmodified event",
+			mutate: func(e *event.Event) {},
+			expect: true,
+		},
+		{
+			name:   "SHOULD reject modified content",
+			mutate: func(e *event.Event) { e.Content += " " },
+			expect: false,
+		},
+		{
+			name:   "SHOULD reject modified tags",
+			mutate: func(e *event.Event) { e.Tags = append(e.Tags, tag.Tag{"t", "nostr"}) },
+			expect: false,
+		},
+		{
+			name:   "SHOULD reject modified created_at",
+			mutate: func(e *event.Event) { e.CreatedAt++ },
+			expect: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evt := new(event.Event)
+			if err := evt.Unmarshal([]byte(vectors[1])); err != nil {
+				t.Fatal(err)
+			}
+			tt.mutate(evt)
+			if got := evt.CheckID(); got != tt.expect {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
 func TestEvent_Sign(t *testing.T) {
 	prvKey, err := btcec.NewPrivateKey()
 	if err != nil {
@@ -134,11 +216,21 @@ func TestEvent_Sign(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			name: "SHOULD return error for invalid private key",
+			args: args{
+				prvKeyHex: "invalid",
+			},
+			fields: fields{
+				event: event.New(1, "content"),
+			},
+			err: errors.New("invalid private key"),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := tt.fields.event.Sign(tt.args.prvKeyHex)
-			if err != nil {
+			if (err != nil) != (tt.err != nil) {
 				t.Errorf("expected %s, got %s", tt.err, err)
 			}
 			t.Logf("got %s", err)
@@ -176,11 +268,49 @@ func TestEvent_Verify(t *testing.T) {
 			wantErr: false,
 		},
 	}
+	for _, v := range vectors {
+		tests = append(tests, struct {
+			name    string
+			fields  fields
+			err     error
+			wantErr bool
+		}{
+			name: "SHOULD verify cross-implementation Event",
+			fields: fields{
+				event: (func() *event.Event {
+					evt := new(event.Event)
+					if err := evt.Unmarshal([]byte(v)); err != nil {
+						t.Error(err)
+					}
+					return evt
+				})(),
+			},
+		})
+	}
+	tests = append(tests, struct {
+		name    string
+		fields  fields
+		err     error
+		wantErr bool
+	}{
+		name: "SHOULD reject Event with mismatched ID",
+		fields: fields{
+			event: (func() *event.Event {
+				evt := new(event.Event)
+				if err := evt.Unmarshal([]byte(vectors[0])); err != nil {
+					t.Error(err)
+				}
+				evt.ID = strings.Repeat("0", 64)
+				return evt
+			})(),
+		},
+		wantErr: true,
+	})
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := tt.fields.event.Verify()
-			if err != nil {
-				t.Error(err)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
 			}
 			t.Logf("got %s", err)
 		})
@@ -0,0 +1,42 @@
+package event
+
+import "github.com/go-nostr/nostr/tag/identifiertag"
+
+// IsRegular reports whether events of the kind are regular events that are
+// expected to be stored by relays.
+func IsRegular(kind int) bool {
+	return !IsReplaceable(kind) && !IsEphemeral(kind) && !IsParameterizedReplaceable(kind)
+}
+
+// IsReplaceable reports whether only the latest event of the kind should be
+// stored per pubkey. For more information, visit:
+// https://github.com/nostr-protocol/nips/blob/master/16.md
+func IsReplaceable(kind int) bool {
+	return kind == 0 || kind == 3 || (kind >= 10000 && kind < 20000)
+}
+
+// IsEphemeral reports whether events of the kind are not expected to be
+// stored by relays.
+func IsEphemeral(kind int) bool {
+	return kind >= 20000 && kind < 30000
+}
+
+// IsParameterizedReplaceable reports whether only the latest event of the kind
+// should be stored per pubkey and "d" tag. For more information, visit:
+// https://github.com/nostr-protocol/nips/blob/master/33.md
+func IsParameterizedReplaceable(kind int) bool {
+	return kind >= 30000 && kind < 40000
+}
+
+// Identifier returns the value of the first "d" tag of the event, or an empty
+// string when the event has none.
+func (e *Event) Identifier() string {
+	for _, t := range e.Tags {
+		if len(t) > 1 && t[0] == identifiertag.Type {
+			if v, ok := t[1].(string); ok {
+				return v
+			}
+		}
+	}
+	return ""
+}
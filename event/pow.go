@@ -0,0 +1,143 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"math/bits"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-nostr/nostr/tag"
+	"github.com/go-nostr/nostr/tag/noncetag"
+)
+
+// Difficulty returns the proof-of-work difficulty of an event ID, which is the
+// number of leading zero bits of the hex encoded ID. For more information,
+// visit: https://github.com/nostr-protocol/nips/blob/master/13.md
+func Difficulty(id string) int {
+	count := 0
+	for i := 0; i < len(id); i++ {
+		n, err := strconv.ParseUint(id[i:i+1], 16, 8)
+		if err != nil {
+			break
+		}
+		if n != 0 {
+			return count + bits.LeadingZeros8(uint8(n)) - 4
+		}
+		count += 4
+	}
+	return count
+}
+
+// CheckPoW checks that the event ID meets the minimum difficulty and that the
+// nonce tag commits to a target of at least the same difficulty, so events
+// mined for a lower target that happen to get lucky are rejected.
+func (e *Event) CheckPoW(minDifficulty int) error {
+	if minDifficulty <= 0 {
+		return nil
+	}
+	if difficulty := Difficulty(e.ID); difficulty < minDifficulty {
+		return fmt.Errorf("difficulty %d is less than %d", difficulty, minDifficulty)
+	}
+	for _, t := range e.Tags {
+		if len(t) == 0 || t[0] != noncetag.Type {
+			continue
+		}
+		target, err := noncetag.Target(t)
+		if err != nil {
+			return err
+		}
+		if target < minDifficulty {
+			return fmt.Errorf("committed target %d is less than %d", target, minDifficulty)
+		}
+		return nil
+	}
+	return fmt.Errorf("missing nonce tag")
+}
+
+// Mine searches for a nonce that gives the event ID at least the target
+// difficulty, using one worker goroutine per CPU. On success the nonce tag and
+// ID of the event are updated; the event still has to be signed afterwards.
+// PubKey must be set before mining because it is part of the hashed data.
+// Mining stops with the context error when ctx is cancelled.
+func Mine(ctx context.Context, evt *Event, targetDifficulty int) error {
+	if evt.PubKey == "" {
+		return fmt.Errorf("event pubkey must be set before mining")
+	}
+	if evt.CreatedAt == 0 {
+		evt.CreatedAt = int(time.Now().Unix())
+	}
+	tags := make([]tag.Tag, 0, len(evt.Tags)+1)
+	for _, t := range evt.Tags {
+		if len(t) > 0 && t[0] == noncetag.Type {
+			continue
+		}
+		tags = append(tags, t)
+	}
+	evt.Tags = append(tags, noncetag.New(0, targetDifficulty))
+	// NOTE: The nonce is the only part of the serialized event that changes,
+	// so everything before and after it is computed once up front.
+	var suffix bytes.Buffer
+	suffix.WriteString(`","` + strconv.Itoa(targetDifficulty) + `"]],`)
+	writeString(&suffix, evt.Content)
+	suffix.WriteByte(']')
+	data := evt.Serialize()
+	prefix := data[:len(data)-suffix.Len()-1]
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		found = make(chan uint64, 1)
+		wg    sync.WaitGroup
+	)
+	workers := runtime.NumCPU()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(nonce uint64) {
+			defer wg.Done()
+			buf := make([]byte, 0, len(prefix)+20+suffix.Len())
+			for n := 0; ; n++ {
+				if n%1024 == 0 && ctx.Err() != nil {
+					return
+				}
+				buf = append(buf[:0], prefix...)
+				buf = strconv.AppendUint(buf, nonce, 10)
+				buf = append(buf, suffix.Bytes()...)
+				hash := sha256.Sum256(buf)
+				if difficulty(hash[:]) >= targetDifficulty {
+					select {
+					case found <- nonce:
+						cancel()
+					default:
+					}
+					return
+				}
+				nonce += uint64(workers)
+			}
+		}(uint64(i))
+	}
+	wg.Wait()
+	select {
+	case nonce := <-found:
+		evt.Tags[len(evt.Tags)-1] = noncetag.New(nonce, targetDifficulty)
+		evt.ID = evt.ComputeID()
+		return nil
+	default:
+		return ctx.Err()
+	}
+}
+
+// difficulty counts the leading zero bits of a hash.
+func difficulty(hash []byte) int {
+	count := 0
+	for _, b := range hash {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
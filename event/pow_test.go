@@ -0,0 +1,144 @@
+package event_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/tag"
+)
+
+func Test_Difficulty(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     string
+		expect int
+	}{
+		{
+			name:   "SHOULD count leading zero bits of NIP-13 example",
+			id:     "000000000e9d97a1ab09fc381030b346cdd7a142ad57e6df0b46dc9bef6c7e2d",
+			expect: 36,
+		},
+		{
+			name:   "SHOULD count partial nibble",
+			id:     "002f000000000000000000000000000000000000000000000000000000000000",
+			expect: 10,
+		},
+		{
+			name:   "SHOULD return zero without leading zeros",
+			id:     "f000000000000000000000000000000000000000000000000000000000000000",
+			expect: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := event.Difficulty(tt.id)
+			if got != tt.expect {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+			t.Logf("got %v", got)
+		})
+	}
+}
+
+func Test_Mine(t *testing.T) {
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey, _ := signer.GetPublicKey(context.TODO())
+	t.Run("SHOULD mine event to target difficulty", func(t *testing.T) {
+		evt := event.New(1, "It's just me mining my own business", tag.Tag{"nonce", "1", "1"})
+		evt.PubKey = pubKey
+		if err := event.Mine(context.TODO(), evt, 12); err != nil {
+			t.Fatal(err)
+		}
+		if got := event.Difficulty(evt.ID); got < 12 {
+			t.Errorf("expected difficulty of at least 12, got %v", got)
+		}
+		if len(evt.Tags) != 1 {
+			t.Errorf("expected nonce tag to be replaced, got %v", evt.Tags)
+		}
+		if err := evt.SignWith(context.TODO(), signer); err != nil {
+			t.Fatal(err)
+		}
+		if err := evt.CheckPoW(12); err != nil {
+			t.Error(err)
+		}
+		t.Logf("got %v", evt)
+	})
+	t.Run("SHOULD stop mining when context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+		defer cancel()
+		evt := event.New(1, "content")
+		evt.PubKey = pubKey
+		err := event.Mine(ctx, evt, 256)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+		}
+	})
+	t.Run("SHOULD require pubkey", func(t *testing.T) {
+		if err := event.Mine(context.TODO(), event.New(1, "content"), 1); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
+
+func TestEvent_CheckPoW(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   *event.Event
+		min     int
+		wantErr bool
+	}{
+		{
+			name: "SHOULD accept difficulty and committed target",
+			event: &event.Event{
+				ID:   "000000000e9d97a1ab09fc381030b346cdd7a142ad57e6df0b46dc9bef6c7e2d",
+				Tags: []tag.Tag{{"nonce", "776797", "20"}},
+			},
+			min: 20,
+		},
+		{
+			name: "SHOULD reject insufficient difficulty",
+			event: &event.Event{
+				ID:   "0f0000000e9d97a1ab09fc381030b346cdd7a142ad57e6df0b46dc9bef6c7e2d",
+				Tags: []tag.Tag{{"nonce", "776797", "20"}},
+			},
+			min:     20,
+			wantErr: true,
+		},
+		{
+			name: "SHOULD reject lucky event with lower committed target",
+			event: &event.Event{
+				ID:   "000000000e9d97a1ab09fc381030b346cdd7a142ad57e6df0b46dc9bef6c7e2d",
+				Tags: []tag.Tag{{"nonce", "776797", "10"}},
+			},
+			min:     20,
+			wantErr: true,
+		},
+		{
+			name: "SHOULD reject missing nonce tag",
+			event: &event.Event{
+				ID: "000000000e9d97a1ab09fc381030b346cdd7a142ad57e6df0b46dc9bef6c7e2d",
+			},
+			min:     20,
+			wantErr: true,
+		},
+		{
+			name:  "SHOULD accept anything without minimum",
+			event: &event.Event{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.event.CheckPoW(tt.min)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+			t.Logf("got %v", err)
+		})
+	}
+}
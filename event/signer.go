@@ -0,0 +1,93 @@
+package event
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/go-nostr/nostr/keys"
+)
+
+// ErrZeroed is returned by a KeySigner used after its key was wiped.
+var ErrZeroed = errors.New("signer key has been wiped")
+
+// Signer signs events on behalf of a single key. Implementations may keep the
+// key in memory, in a local daemon or behind a remote signing service.
+type Signer interface {
+	// GetPublicKey returns the hex encoded x-only public key of the signer.
+	GetPublicKey(ctx context.Context) (string, error)
+	// SignEvent sets the PubKey, ID and Sig of the given event.
+	SignEvent(ctx context.Context, evt *Event) error
+}
+
+// NewKeySigner creates a Signer from a hex encoded private key.
+func NewKeySigner(prvKeyHex string) (*KeySigner, error) {
+	prvKey, err := keys.ParsePrivateKey(prvKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySignerFromKey(prvKey), nil
+}
+
+// NewKeySignerFromKey creates a Signer from a private key, without making a
+// copy of it. The signer takes ownership of the key, which Zero wipes.
+func NewKeySignerFromKey(prvKey *keys.PrivateKey) *KeySigner {
+	return &KeySigner{
+		prvKey: prvKey,
+		pubKey: prvKey.PublicKey().Hex(),
+	}
+}
+
+// KeySigner is a Signer backed by a private key held in memory. Call Zero to
+// wipe the key once the signer is no longer needed; it must not be used
+// concurrently with Zero.
+type KeySigner struct {
+	prvKey *keys.PrivateKey
+	pubKey string
+}
+
+// GetPublicKey returns the hex encoded x-only public key of the signer.
+func (s *KeySigner) GetPublicKey(ctx context.Context) (string, error) {
+	return s.pubKey, nil
+}
+
+// SharedSecret returns the ECDH shared secret of the signer and the hex
+// encoded public key, from which encryption schemes derive their keys. The
+// caller should wipe it once it is no longer needed.
+func (s *KeySigner) SharedSecret(pubKeyHex string) ([]byte, error) {
+	if s.prvKey == nil {
+		return nil, ErrZeroed
+	}
+	pubKey, err := keys.ParsePublicKey(pubKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	return s.prvKey.SharedSecret(pubKey)
+}
+
+// SignEvent sets the PubKey, ID and Sig of the given event.
+func (s *KeySigner) SignEvent(ctx context.Context, evt *Event) error {
+	if s.prvKey == nil {
+		return ErrZeroed
+	}
+	evt.PubKey = s.pubKey
+	hash := sha256.Sum256(evt.Serialize())
+	sig, err := s.prvKey.Sign(hash[:])
+	if err != nil {
+		return fmt.Errorf("unable to sign event: %w", err)
+	}
+	evt.ID = hex.EncodeToString(hash[:])
+	evt.Sig = hex.EncodeToString(sig)
+	return nil
+}
+
+// Zero wipes the key of the signer from memory. The signer fails with
+// ErrZeroed afterwards.
+func (s *KeySigner) Zero() {
+	if s.prvKey != nil {
+		s.prvKey.Zero()
+		s.prvKey = nil
+	}
+}
@@ -0,0 +1,200 @@
+package event_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/keys"
+)
+
+func Test_NewKeySigner(t *testing.T) {
+	type args struct {
+		prvKeyHex string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		expect  string
+		wantErr bool
+	}{
+		{
+			name: "SHOULD create KeySigner from hex private key",
+			args: args{
+				prvKeyHex: "0000000000000000000000000000000000000000000000000000000000000003",
+			},
+			expect: "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+		},
+		{
+			name: "SHOULD reject invalid hex",
+			args: args{
+				prvKeyHex: "not hex",
+			},
+			wantErr: true,
+		},
+		{
+			name: "SHOULD reject short private key",
+			args: args{
+				prvKeyHex: "0003",
+			},
+			wantErr: true,
+		},
+		{
+			name: "SHOULD reject zero private key",
+			args: args{
+				prvKeyHex: "0000000000000000000000000000000000000000000000000000000000000000",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := event.NewKeySigner(tt.args.prvKeyHex)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				t.Logf("got %v", err)
+				return
+			}
+			got, err := signer.GetPublicKey(context.TODO())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.expect {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+			t.Logf("got %v", got)
+		})
+	}
+}
+
+func TestNewKeySignerFromKey(t *testing.T) {
+	prvKey, err := keys.ParsePrivateKey("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := event.NewKeySignerFromKey(prvKey)
+	got, err := signer.GetPublicKey(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9"
+	if got != expect {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+	signer.Zero()
+	if prvKey.Hex() != strings.Repeat("0", 64) {
+		t.Errorf("expected wiped key, got %v", prvKey.Hex())
+	}
+}
+
+func TestKeySigner_Zero(t *testing.T) {
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer.Zero()
+	err = event.New(1, "content").SignWith(context.TODO(), signer)
+	t.Logf("got %v", err)
+	if !errors.Is(err, event.ErrZeroed) {
+		t.Errorf("expected %v, got %v", event.ErrZeroed, err)
+	}
+}
+
+// brokenSigner returns a signature that does not match the event.
+type brokenSigner struct {
+	*event.KeySigner
+}
+
+func (s brokenSigner) SignEvent(ctx context.Context, evt *event.Event) error {
+	if err := s.KeySigner.SignEvent(ctx, evt); err != nil {
+		return err
+	}
+	evt.Content += "!"
+	return nil
+}
+
+// failingSigner always refuses to sign.
+type failingSigner struct{}
+
+func (failingSigner) GetPublicKey(ctx context.Context) (string, error) {
+	return "", fmt.Errorf("unavailable")
+}
+
+func (failingSigner) SignEvent(ctx context.Context, evt *event.Event) error {
+	return fmt.Errorf("unavailable")
+}
+
+func TestEvent_SignWith(t *testing.T) {
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		signer    event.Signer
+		event     *event.Event
+		expectID  string
+		wantErr   bool
+		createdAt int
+	}{
+		{
+			name:      "SHOULD keep caller-set CreatedAt",
+			signer:    signer,
+			event:     &event.Event{Kind: 1, Content: "hello world", CreatedAt: 1672068534},
+			expectID:  "5020d406443aead0f7bcfbb435215dd67ecb0a1e611f8428b4a7d24f8df03ab1",
+			createdAt: 1672068534,
+		},
+		{
+			name:   "SHOULD set CreatedAt when unset",
+			signer: signer,
+			event:  event.New(1, "content"),
+		},
+		{
+			name:    "SHOULD reject nil signer",
+			event:   event.New(1, "content"),
+			wantErr: true,
+		},
+		{
+			name:    "SHOULD return signer error",
+			signer:  failingSigner{},
+			event:   event.New(1, "content"),
+			wantErr: true,
+		},
+		{
+			name:    "SHOULD reject invalid signature from signer",
+			signer:  brokenSigner{signer},
+			event:   event.New(1, "content"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.event.SignWith(context.TODO(), tt.signer)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				t.Logf("got %v", err)
+				return
+			}
+			if tt.createdAt != 0 && tt.event.CreatedAt != tt.createdAt {
+				t.Errorf("expected created_at %v, got %v", tt.createdAt, tt.event.CreatedAt)
+			}
+			if tt.event.CreatedAt == 0 {
+				t.Errorf("expected created_at to be set")
+			}
+			if tt.expectID != "" && tt.event.ID != tt.expectID {
+				t.Errorf("expected id %v, got %v", tt.expectID, tt.event.ID)
+			}
+			if err := tt.event.Verify(); err != nil {
+				t.Error(err)
+			}
+			t.Logf("got %v", tt.event)
+		})
+	}
+}
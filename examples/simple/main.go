@@ -49,8 +49,13 @@ func main() {
 		ReadLimit: 2e6,
 	})
 
-	// Connect the Nostr client to the relay server using the provided URL.
-	cl.Connect(ctx, relayURL)
+	// Connect the Nostr client to the relay server using the provided URL. If
+	// the connection fails, print the error and exit the program with a
+	// non-zero status code.
+	if err := cl.Connect(ctx, relayURL); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
 
 	// Register an error handling function that will print out any errors that
 	// occur while the client is running.
@@ -67,7 +72,10 @@ func main() {
 	// Send a subscription message to the relay server using the provided
 	// Subscription ID. The subscription message has an empty filter which
 	// means it will receive all messages.
-	cl.SendMessage(ctx, requestmessage.New(subscriptionID, &requestmessage.Filter{}))
+	if err := cl.SendMessage(ctx, requestmessage.New(subscriptionID, &requestmessage.Filter{})); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
 
 	// Start listening for incoming messages. If any error occurs while
 	// listening, print the error and exit the program with a non-zero status
@@ -4,6 +4,8 @@ go 1.20
 
 require (
 	github.com/google/wire v0.5.0
+	go.etcd.io/bbolt v1.3.8
+	golang.org/x/crypto v0.14.0
 	golang.org/x/sync v0.1.0
 	nhooyr.io/websocket v1.8.7
 )
@@ -12,6 +14,7 @@ require (
 	github.com/btcsuite/btcd/chaincfg/chainhash v1.0.1 // indirect
 	github.com/decred/dcrd/crypto/blake256 v1.0.0 // indirect
 	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.0.1 // indirect
+	golang.org/x/sys v0.13.0 // indirect
 )
 
 require (
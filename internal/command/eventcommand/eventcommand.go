@@ -2,16 +2,15 @@ package eventcommand
 
 import (
 	"context"
-	"encoding/hex"
 	"flag"
 	"fmt"
 	"os"
 
-	"github.com/btcsuite/btcd/btcutil/bech32"
 	"github.com/go-nostr/nostr/client"
+	"github.com/go-nostr/nostr/event"
 	"github.com/go-nostr/nostr/event/shorttextnote"
+	"github.com/go-nostr/nostr/keys"
 	"github.com/go-nostr/nostr/message"
-	"github.com/go-nostr/nostr/message/eventmessage"
 )
 
 func New(opt *Options) *EventCommand {
@@ -49,37 +48,28 @@ func (c *EventCommand) Name() string {
 }
 
 func (c *EventCommand) Run() error {
-	ctx := context.Background()
-	errChan := make(chan error)
-	msgChan := make(chan message.Message)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	c.Client.HandleErrorFunc(func(err error) {
-		errChan <- err
+		fmt.Fprintln(os.Stderr, err)
 	})
-	c.Client.HandleMessageFunc(func(msg message.Message) {
-		msgChan <- msg
-	})
-	c.Client.Connect(ctx, c.Relay)
-	_, nsecData, err := bech32.DecodeNoLimit(c.Nsec)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	c.Client.HandleMessageFunc(func(msg message.Message) {})
+	go c.Client.Listen(ctx)
+	if err := c.Client.Connect(ctx, c.Relay); err != nil {
+		return err
 	}
-	prvKey, err := bech32.ConvertBits(nsecData, 5, 8, false)
+	prvKey, err := keys.DecodeNsec(c.Nsec)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return err
 	}
-	prvKeyHex := hex.EncodeToString(prvKey[0:32])
+	signer := event.NewKeySignerFromKey(prvKey)
+	defer signer.Zero()
 	evt := shorttextnote.New(c.Content)
-	evt.Sign(prvKeyHex)
-	outMsg := eventmessage.New("", evt)
-	c.Client.SendMessage(ctx, outMsg)
-	inMsg := <-msgChan
-	inMsgByt, err := inMsg.Marshal()
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	if err := evt.SignWith(ctx, signer); err != nil {
+		return err
+	}
+	for _, res := range c.Client.Publish(ctx, evt, c.Relay) {
+		fmt.Println(res)
 	}
-	fmt.Printf("%s", inMsgByt)
 	return nil
 }
@@ -47,9 +47,13 @@ func (c *RequestCommand) Run() error {
 	c.client.HandleMessageFunc(func(msg message.Message) {
 		fmt.Println(msg.Values()...)
 	})
-	c.client.Connect(ctx, c.relay)
+	if err := c.client.Connect(ctx, c.relay); err != nil {
+		return err
+	}
 	msg := requestmessage.New(c.subscriptionID, &requestmessage.Filter{})
-	c.client.SendMessage(ctx, msg)
+	if err := c.client.SendMessage(ctx, msg); err != nil {
+		return err
+	}
 	if err := c.client.Listen(ctx); err != nil {
 		return err
 	}
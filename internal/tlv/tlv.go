@@ -0,0 +1,124 @@
+// Package tlv encodes and decodes the bech32 type-length-value entities of
+// NIP-19 shared by the nprofile, nevent, naddr and nrelay packages.
+package tlv
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+)
+
+// Types of the TLV entries.
+const (
+	TypeSpecial byte = 0 // TypeSpecial depends on the prefix: a public key, an event ID, an identifier or a relay URL.
+	TypeRelay   byte = 1 // TypeRelay is a relay URL, in ASCII.
+	TypeAuthor  byte = 2 // TypeAuthor is the 32 bytes public key of the author.
+	TypeKind    byte = 3 // TypeKind is the event kind, as a 32 bits unsigned big-endian integer.
+)
+
+var (
+	// ErrPrefix is returned when decoding an entity with another prefix than
+	// expected.
+	ErrPrefix = errors.New("unexpected prefix")
+	// ErrTruncated is returned when a TLV entry is longer than the data left.
+	ErrTruncated = errors.New("truncated tlv")
+)
+
+// Entry is a TLV entry.
+type Entry struct {
+	Type  byte
+	Value []byte
+}
+
+// Encode encodes the entries as bech32 with the human-readable prefix.
+func Encode(prefix string, entries []Entry) (string, error) {
+	var data []byte
+	for _, e := range entries {
+		if len(e.Value) > 255 {
+			return "", fmt.Errorf("tlv value of type %d is longer than 255 bytes", e.Type)
+		}
+		data = append(data, e.Type, byte(len(e.Value)))
+		data = append(data, e.Value...)
+	}
+	grp, err := bech32.ConvertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return bech32.Encode(prefix, grp)
+}
+
+// Decode decodes the bech32 entity, which must have the human-readable prefix,
+// into its TLV entries.
+func Decode(prefix string, s string) ([]Entry, error) {
+	hrp, grp, err := bech32.DecodeNoLimit(s)
+	if err != nil {
+		return nil, err
+	}
+	if hrp != prefix {
+		return nil, fmt.Errorf("%w: expected %q, got %q", ErrPrefix, prefix, hrp)
+	}
+	data, err := bech32.ConvertBits(grp, 5, 8, false)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for len(data) > 0 {
+		if len(data) < 2 || len(data) < 2+int(data[1]) {
+			return nil, ErrTruncated
+		}
+		n := int(data[1])
+		entries = append(entries, Entry{Type: data[0], Value: data[2 : 2+n]})
+		data = data[2+n:]
+	}
+	return entries, nil
+}
+
+// Hex32 returns the entry of the type holding the hex encoded 32 bytes value.
+func Hex32(typ byte, value string) (Entry, error) {
+	b, err := hex.DecodeString(value)
+	if err != nil || len(b) != 32 {
+		return Entry{}, fmt.Errorf("invalid hex encoded 32 bytes value %q", value)
+	}
+	return Entry{Type: typ, Value: b}, nil
+}
+
+// Kind returns the kind entry.
+func Kind(kind int) (Entry, error) {
+	if kind < 0 || uint64(kind) > math.MaxUint32 {
+		return Entry{}, fmt.Errorf("invalid kind %d", kind)
+	}
+	return Entry{Type: TypeKind, Value: binary.BigEndian.AppendUint32(nil, uint32(kind))}, nil
+}
+
+// Relays returns the relay entries.
+func Relays(relays []string) []Entry {
+	entries := make([]Entry, len(relays))
+	for i, r := range relays {
+		entries[i] = Entry{Type: TypeRelay, Value: []byte(r)}
+	}
+	return entries
+}
+
+// ParseHex32 returns the hex encoding of the 32 bytes value of the entry.
+func ParseHex32(e Entry) (string, error) {
+	if len(e.Value) != 32 {
+		return "", fmt.Errorf("invalid tlv value of type %d: expected 32 bytes, got %d", e.Type, len(e.Value))
+	}
+	return hex.EncodeToString(e.Value), nil
+}
+
+// ParseKind returns the kind of the entry.
+func ParseKind(e Entry) (int, error) {
+	if len(e.Value) != 4 {
+		return 0, fmt.Errorf("invalid tlv kind: expected 4 bytes, got %d", len(e.Value))
+	}
+	kind := binary.BigEndian.Uint32(e.Value)
+	if uint64(kind) > uint64(math.MaxInt) {
+		return 0, fmt.Errorf("invalid tlv kind: %d overflows int", kind)
+	}
+	return int(kind), nil
+}
@@ -0,0 +1,245 @@
+// Package keys handles the secp256k1 keys of nostr: parsing and formatting them
+// as hex and as NIP-19 "nsec" and "npub" bech32 entities, deriving public keys
+// and wiping private keys from memory.
+package keys
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+)
+
+// Prefixes of the bech32 encoded keys.
+const (
+	PrefixPrivateKey = "nsec"
+	PrefixPublicKey  = "npub"
+)
+
+// Size is the size of keys in bytes.
+const Size = 32
+
+var (
+	// ErrChecksum is returned when a bech32 key has an invalid checksum.
+	ErrChecksum = errors.New("invalid checksum")
+	// ErrEncoding is returned when a key is not valid hex or bech32.
+	ErrEncoding = errors.New("invalid encoding")
+	// ErrInvalidKey is returned when a key is not a valid secp256k1 key.
+	ErrInvalidKey = errors.New("invalid key")
+	// ErrLength is returned when a key is not 32 bytes long.
+	ErrLength = errors.New("invalid length")
+	// ErrPrefix is returned when a bech32 key has another prefix than
+	// expected.
+	ErrPrefix = errors.New("unexpected prefix")
+)
+
+// PrivateKey is a secp256k1 private key. Call Zero to wipe it from memory once
+// it is no longer needed.
+type PrivateKey struct {
+	key [Size]byte
+}
+
+// GeneratePrivateKey generates a random private key.
+func GeneratePrivateKey() (*PrivateKey, error) {
+	prvKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	defer prvKey.Zero()
+	k := &PrivateKey{}
+	prvKey.Key.PutBytes(&k.key)
+	return k, nil
+}
+
+// ParsePrivateKey parses a hex encoded private key.
+func ParsePrivateKey(prvKeyHex string) (*PrivateKey, error) {
+	data, err := decodeHex(prvKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	defer Wipe(data)
+	return newPrivateKey(data)
+}
+
+// DecodeNsec decodes a bech32 private key with the "nsec" prefix.
+func DecodeNsec(nsec string) (*PrivateKey, error) {
+	data, err := decodeBech32(PrefixPrivateKey, nsec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nsec: %w", err)
+	}
+	defer Wipe(data)
+	return newPrivateKey(data)
+}
+
+// Hex returns the hex encoding of the private key.
+func (k *PrivateKey) Hex() string {
+	return hex.EncodeToString(k.key[:])
+}
+
+// Nsec returns the bech32 encoding of the private key with the "nsec" prefix.
+func (k *PrivateKey) Nsec() string {
+	return encodeBech32(PrefixPrivateKey, k.key[:])
+}
+
+// PublicKey derives the public key of the private key.
+func (k *PrivateKey) PublicKey() PublicKey {
+	prvKey, pubKey := btcec.PrivKeyFromBytes(k.key[:])
+	defer prvKey.Zero()
+	var p PublicKey
+	copy(p[:], schnorr.SerializePubKey(pubKey))
+	return p
+}
+
+// SharedSecret computes the ECDH shared secret of the private key and the
+// public key of the other party, which is the x coordinate of the shared point.
+// Both parties compute the same secret.
+func (k *PrivateKey) SharedSecret(p PublicKey) ([]byte, error) {
+	// x-only public keys are parsed with an even y, which does not change the
+	// x coordinate of the shared point.
+	pubKey, err := btcec.ParsePubKey(append([]byte{0x02}, p[:]...))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w: %v", ErrInvalidKey, err)
+	}
+	prvKey, _ := btcec.PrivKeyFromBytes(k.key[:])
+	defer prvKey.Zero()
+	return btcec.GenerateSharedSecret(prvKey, pubKey), nil
+}
+
+// Sign returns the BIP-340 schnorr signature of the 32 bytes hash.
+func (k *PrivateKey) Sign(hash []byte) ([]byte, error) {
+	prvKey, _ := btcec.PrivKeyFromBytes(k.key[:])
+	defer prvKey.Zero()
+	sig, err := schnorr.Sign(prvKey, hash)
+	if err != nil {
+		return nil, err
+	}
+	return sig.Serialize(), nil
+}
+
+// Zero wipes the private key from memory.
+func (k *PrivateKey) Zero() {
+	Wipe(k.key[:])
+}
+
+// PublicKey is a secp256k1 public key in the 32 bytes x-only form of BIP-340.
+type PublicKey [Size]byte
+
+// ParsePublicKey parses a hex encoded public key.
+func ParsePublicKey(pubKeyHex string) (PublicKey, error) {
+	data, err := decodeHex(pubKeyHex)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("invalid public key: %w", err)
+	}
+	return newPublicKey(data)
+}
+
+// DecodeNpub decodes a bech32 public key with the "npub" prefix.
+func DecodeNpub(npub string) (PublicKey, error) {
+	data, err := decodeBech32(PrefixPublicKey, npub)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("invalid npub: %w", err)
+	}
+	return newPublicKey(data)
+}
+
+// Hex returns the hex encoding of the public key.
+func (p PublicKey) Hex() string {
+	return hex.EncodeToString(p[:])
+}
+
+// Npub returns the bech32 encoding of the public key with the "npub" prefix.
+func (p PublicKey) Npub() string {
+	return encodeBech32(PrefixPublicKey, p[:])
+}
+
+// String returns the hex encoding of the public key.
+func (p PublicKey) String() string {
+	return p.Hex()
+}
+
+// newPrivateKey copies the 32 bytes private key, which must be in the range
+// of the curve order.
+func newPrivateKey(data []byte) (*PrivateKey, error) {
+	var s btcec.ModNScalar
+	if overflow := s.SetByteSlice(data); overflow || s.IsZero() {
+		s.Zero()
+		return nil, fmt.Errorf("invalid private key: %w: out of range", ErrInvalidKey)
+	}
+	s.Zero()
+	k := &PrivateKey{}
+	copy(k.key[:], data)
+	return k, nil
+}
+
+// newPublicKey copies the 32 bytes public key, which must be on the curve.
+func newPublicKey(data []byte) (PublicKey, error) {
+	if _, err := schnorr.ParsePubKey(data); err != nil {
+		return PublicKey{}, fmt.Errorf("invalid public key: %w: %v", ErrInvalidKey, err)
+	}
+	var p PublicKey
+	copy(p[:], data)
+	return p, nil
+}
+
+// decodeHex decodes the hex encoded 32 bytes key.
+func decodeHex(s string) ([]byte, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrEncoding, err)
+	}
+	if len(data) != Size {
+		Wipe(data)
+		return nil, fmt.Errorf("%w: expected %d bytes, got %d", ErrLength, Size, len(data))
+	}
+	return data, nil
+}
+
+// decodeBech32 decodes the bech32 encoded 32 bytes key with the prefix.
+func decodeBech32(prefix string, s string) ([]byte, error) {
+	hrp, grp, err := bech32.DecodeNoLimit(s)
+	if err != nil {
+		var checksum bech32.ErrInvalidChecksum
+		if errors.As(err, &checksum) {
+			return nil, ErrChecksum
+		}
+		return nil, fmt.Errorf("%w: %v", ErrEncoding, err)
+	}
+	if hrp != prefix {
+		return nil, fmt.Errorf("%w: expected %q, got %q", ErrPrefix, prefix, hrp)
+	}
+	data, err := bech32.ConvertBits(grp, 5, 8, false)
+	Wipe(grp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrEncoding, err)
+	}
+	if len(data) != Size {
+		Wipe(data)
+		return nil, fmt.Errorf("%w: expected %d bytes, got %d", ErrLength, Size, len(data))
+	}
+	return data, nil
+}
+
+// encodeBech32 encodes the key as bech32 with the prefix.
+func encodeBech32(prefix string, data []byte) string {
+	grp, err := bech32.ConvertBits(data, 8, 5, true)
+	if err != nil {
+		panic(err) // unreachable: converting bytes with padding never fails
+	}
+	defer Wipe(grp)
+	s, err := bech32.Encode(prefix, grp)
+	if err != nil {
+		panic(err) // unreachable: the prefixes and data are valid
+	}
+	return s
+}
+
+// Wipe overwrites the bytes with zeros, to remove secrets derived from keys
+// from memory.
+func Wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
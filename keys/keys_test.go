@@ -0,0 +1,147 @@
+package keys_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/go-nostr/nostr/keys"
+)
+
+func encode(t *testing.T, prefix string, data []byte) string {
+	t.Helper()
+	grp, err := bech32.ConvertBits(data, 8, 5, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := bech32.Encode(prefix, grp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestDecodeNsec(t *testing.T) {
+	tests := []struct {
+		name   string
+		nsec   string
+		expect string
+		err    error
+	}{
+		{
+			name:   "SHOULD decode nsec",
+			nsec:   "nsec1vl029mgpspedva04g90vltkh6fvh240zqtv9k0t9af8935ke9laqsnlfe5",
+			expect: "67dea2ed018072d675f5415ecfaed7d2597555e202d85b3d65ea4e58d2d92ffa",
+		},
+		{
+			name: "SHOULD reject bad checksum",
+			nsec: "nsec1vl029mgpspedva04g90vltkh6fvh240zqtv9k0t9af8935ke9laqsnlfe6",
+			err:  keys.ErrChecksum,
+		},
+		{
+			name: "SHOULD reject npub",
+			nsec: "npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjptg",
+			err:  keys.ErrPrefix,
+		},
+		{
+			name: "SHOULD reject short key",
+			nsec: encode(t, keys.PrefixPrivateKey, []byte{1, 2, 3}),
+			err:  keys.ErrLength,
+		},
+		{
+			name: "SHOULD reject zero key",
+			nsec: encode(t, keys.PrefixPrivateKey, make([]byte, 32)),
+			err:  keys.ErrInvalidKey,
+		},
+		{
+			name: "SHOULD reject garbage",
+			nsec: "not a key",
+			err:  keys.ErrEncoding,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prvKey, err := keys.DecodeNsec(tt.nsec)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if got := prvKey.Hex(); tt.expect != got {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+			if got := prvKey.Nsec(); tt.nsec != got {
+				t.Errorf("expected %v, got %v", tt.nsec, got)
+			}
+			t.Logf("got %v", prvKey.Hex())
+		})
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		hex    string
+		expect string
+		err    error
+	}{
+		{
+			name:   "SHOULD parse public key",
+			hex:    "7e7e9c42a91bfef19fa929e5fda1b72e0ebc1a4c1141673e2794234d86addf4e",
+			expect: "npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjptg",
+		},
+		{
+			name: "SHOULD reject invalid hex",
+			hex:  "7e7e9c42a91bfef19fa929e5fda1b72e0ebc1a4c1141673e2794234d86addfzz",
+			err:  keys.ErrEncoding,
+		},
+		{
+			name: "SHOULD reject short key",
+			hex:  "7e7e9c42",
+			err:  keys.ErrLength,
+		},
+		{
+			name: "SHOULD reject point not on curve",
+			hex:  "0000000000000000000000000000000000000000000000000000000000000000",
+			err:  keys.ErrInvalidKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pubKey, err := keys.ParsePublicKey(tt.hex)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if got := pubKey.Npub(); tt.expect != got {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+			got, err := keys.DecodeNpub(tt.expect)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pubKey != got {
+				t.Errorf("expected %v, got %v", pubKey, got)
+			}
+			t.Logf("got %v", got)
+		})
+	}
+}
+
+func TestPrivateKey_PublicKey(t *testing.T) {
+	prvKey, err := keys.ParsePrivateKey("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9"
+	if got := prvKey.PublicKey().Hex(); expect != got {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+	prvKey.Zero()
+	if got := prvKey.Hex(); got != "0000000000000000000000000000000000000000000000000000000000000000" {
+		t.Errorf("expected zeroed key, got %v", got)
+	}
+}
@@ -6,7 +6,7 @@ import (
 )
 
 // Type for message "AUTH"
-const Type = "AUTH"
+const Type = message.TypeAuth
 
 // New creates a new "AUTH" message
 func New(challenge string, evt *event.Event) message.Message {
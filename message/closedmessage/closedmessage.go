@@ -0,0 +1,11 @@
+package closedmessage
+
+import "github.com/go-nostr/nostr/message"
+
+// Type for message "CLOSED"
+const Type = message.TypeClosed
+
+// New creates a new ClosedMessage ending the given subscription with a reason.
+func New(subscriptionID string, reason string) message.Message {
+	return message.New(Type, subscriptionID, reason)
+}
@@ -2,10 +2,10 @@ package closemessage
 
 import "github.com/go-nostr/nostr/message"
 
-// Type TBD
-const Type = "CLOSE"
+// Type for message "CLOSE"
+const Type = message.TypeClose
 
-// New creates a new CloseMessage.
-func New() message.Message {
-	return message.New(Type)
+// New creates a new CloseMessage for the given subscription ID.
+func New(subscriptionID string) message.Message {
+	return message.New(Type, subscriptionID)
 }
@@ -1,5 +1,5 @@
 package countmessage
 
 type Count struct {
-	Count int `json:"count,omitempty"`
+	Count int `json:"count"`
 }
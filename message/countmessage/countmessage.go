@@ -4,12 +4,18 @@ import (
 	"github.com/go-nostr/nostr/message"
 )
 
-const Type = "COUNT"
+const Type = message.TypeCount
 
-// New creates a new CountMessage.
-func New(subscriptionID string, count *Count) message.Message {
+// New creates a new CountMessage. Relays answer with a count, clients request
+// a count by passing a nil count and the filters to count.
+func New(subscriptionID string, count *Count, filter ...*message.Filter) message.Message {
+	msg := message.New(Type, subscriptionID)
 	if count != nil {
-		return message.New(Type, count)
+		msg.Push(count)
+		return msg
 	}
-	return message.New(Type, subscriptionID)
+	for _, f := range filter {
+		msg.Push(f)
+	}
+	return msg
 }
@@ -0,0 +1,353 @@
+package message
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-nostr/nostr/event"
+)
+
+// Message types defined by NIP-01, NIP-20, NIP-42 and NIP-45.
+const (
+	TypeAuth   = "AUTH"
+	TypeClose  = "CLOSE"
+	TypeClosed = "CLOSED"
+	TypeCount  = "COUNT"
+	TypeEOSE   = "EOSE"
+	TypeEvent  = "EVENT"
+	TypeNotice = "NOTICE"
+	TypeOK     = "OK"
+	TypeReq    = "REQ"
+)
+
+// ErrUnknownType is returned by Parse for messages with an unsupported type.
+var ErrUnknownType = errors.New("unknown message type")
+
+// Envelope is a parsed Message with typed values.
+type Envelope interface {
+	// Type returns the message type, such as "EVENT" or "REQ".
+	Type() string
+	// Message returns the raw representation of the envelope.
+	Message() Message
+}
+
+// AuthEnvelope is an "AUTH" message. Relays send a Challenge, clients answer
+// with a signed Event.
+type AuthEnvelope struct {
+	Challenge string
+	Event     *event.Event
+}
+
+// Type returns "AUTH".
+func (e *AuthEnvelope) Type() string { return TypeAuth }
+
+// Message returns the raw "AUTH" message.
+func (e *AuthEnvelope) Message() Message {
+	if e.Event != nil {
+		return New(TypeAuth, e.Event)
+	}
+	return New(TypeAuth, e.Challenge)
+}
+
+// CloseEnvelope is a "CLOSE" message used by clients to stop a subscription.
+type CloseEnvelope struct {
+	SubscriptionID string
+}
+
+// Type returns "CLOSE".
+func (e *CloseEnvelope) Type() string { return TypeClose }
+
+// Message returns the raw "CLOSE" message.
+func (e *CloseEnvelope) Message() Message {
+	return New(TypeClose, e.SubscriptionID)
+}
+
+// ClosedEnvelope is a "CLOSED" message used by relays to end a subscription.
+type ClosedEnvelope struct {
+	SubscriptionID string
+	Reason         string
+}
+
+// Type returns "CLOSED".
+func (e *ClosedEnvelope) Type() string { return TypeClosed }
+
+// Message returns the raw "CLOSED" message.
+func (e *ClosedEnvelope) Message() Message {
+	return New(TypeClosed, e.SubscriptionID, e.Reason)
+}
+
+// CountEnvelope is a "COUNT" message. Clients send Filters, relays answer with
+// a Count.
+type CountEnvelope struct {
+	SubscriptionID string
+	Filters        Filters
+	Count          *int
+}
+
+// Type returns "COUNT".
+func (e *CountEnvelope) Type() string { return TypeCount }
+
+// Message returns the raw "COUNT" message.
+func (e *CountEnvelope) Message() Message {
+	msg := New(TypeCount, e.SubscriptionID)
+	if e.Count != nil {
+		msg.Push(map[string]int{"count": *e.Count})
+		return msg
+	}
+	for _, f := range e.Filters {
+		msg.Push(f)
+	}
+	return msg
+}
+
+// EoseEnvelope is an "EOSE" message marking the end of stored events.
+type EoseEnvelope struct {
+	SubscriptionID string
+}
+
+// Type returns "EOSE".
+func (e *EoseEnvelope) Type() string { return TypeEOSE }
+
+// Message returns the raw "EOSE" message.
+func (e *EoseEnvelope) Message() Message {
+	return New(TypeEOSE, e.SubscriptionID)
+}
+
+// EventEnvelope is an "EVENT" message. SubscriptionID is empty for events
+// published by clients.
+type EventEnvelope struct {
+	SubscriptionID string
+	Event          *event.Event
+}
+
+// Type returns "EVENT".
+func (e *EventEnvelope) Type() string { return TypeEvent }
+
+// Message returns the raw "EVENT" message.
+func (e *EventEnvelope) Message() Message {
+	if e.SubscriptionID == "" {
+		return New(TypeEvent, e.Event)
+	}
+	return New(TypeEvent, e.SubscriptionID, e.Event)
+}
+
+// NoticeEnvelope is a "NOTICE" message containing a human-readable message.
+type NoticeEnvelope struct {
+	Notice string
+}
+
+// Type returns "NOTICE".
+func (e *NoticeEnvelope) Type() string { return TypeNotice }
+
+// Message returns the raw "NOTICE" message.
+func (e *NoticeEnvelope) Message() Message {
+	return New(TypeNotice, e.Notice)
+}
+
+// OkEnvelope is an "OK" message reporting whether an event was accepted.
+type OkEnvelope struct {
+	EventID string
+	OK      bool
+	Reason  string
+}
+
+// Type returns "OK".
+func (e *OkEnvelope) Type() string { return TypeOK }
+
+// Message returns the raw "OK" message.
+func (e *OkEnvelope) Message() Message {
+	return New(TypeOK, e.EventID, e.OK, e.Reason)
+}
+
+// ReqEnvelope is a "REQ" message used to subscribe to events.
+type ReqEnvelope struct {
+	SubscriptionID string
+	Filters        Filters
+}
+
+// Type returns "REQ".
+func (e *ReqEnvelope) Type() string { return TypeReq }
+
+// Message returns the raw "REQ" message.
+func (e *ReqEnvelope) Message() Message {
+	msg := New(TypeReq, e.SubscriptionID)
+	for _, f := range e.Filters {
+		msg.Push(f)
+	}
+	return msg
+}
+
+// Parse parses a JSON encoded message into its typed Envelope.
+func Parse(data []byte) (Envelope, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("invalid message: empty array")
+	}
+	var typ string
+	if err := json.Unmarshal(raw[0], &typ); err != nil {
+		return nil, fmt.Errorf("invalid message: type must be a string")
+	}
+	args := raw[1:]
+	switch typ {
+	case TypeAuth:
+		if len(args) != 1 {
+			return nil, argsError(typ, "1", len(args))
+		}
+		env := new(AuthEnvelope)
+		if json.Unmarshal(args[0], &env.Challenge) == nil {
+			return env, nil
+		}
+		if err := decode(typ, "event", args[0], &env.Event); err != nil {
+			return nil, err
+		}
+		return env, nil
+	case TypeClose:
+		if len(args) != 1 {
+			return nil, argsError(typ, "1", len(args))
+		}
+		env := new(CloseEnvelope)
+		if err := decode(typ, "subscription id", args[0], &env.SubscriptionID); err != nil {
+			return nil, err
+		}
+		return env, nil
+	case TypeClosed:
+		if len(args) != 2 {
+			return nil, argsError(typ, "2", len(args))
+		}
+		env := new(ClosedEnvelope)
+		if err := decode(typ, "subscription id", args[0], &env.SubscriptionID); err != nil {
+			return nil, err
+		}
+		if err := decode(typ, "reason", args[1], &env.Reason); err != nil {
+			return nil, err
+		}
+		return env, nil
+	case TypeCount:
+		if len(args) < 2 {
+			return nil, argsError(typ, "at least 2", len(args))
+		}
+		env := new(CountEnvelope)
+		if err := decode(typ, "subscription id", args[0], &env.SubscriptionID); err != nil {
+			return nil, err
+		}
+		var count struct {
+			Count *int `json:"count"`
+		}
+		if len(args) == 2 && json.Unmarshal(args[1], &count) == nil && count.Count != nil {
+			env.Count = count.Count
+			return env, nil
+		}
+		filters, err := decodeFilters(typ, args[1:])
+		if err != nil {
+			return nil, err
+		}
+		env.Filters = filters
+		return env, nil
+	case TypeEOSE:
+		if len(args) != 1 {
+			return nil, argsError(typ, "1", len(args))
+		}
+		env := new(EoseEnvelope)
+		if err := decode(typ, "subscription id", args[0], &env.SubscriptionID); err != nil {
+			return nil, err
+		}
+		return env, nil
+	case TypeEvent:
+		if len(args) != 1 && len(args) != 2 {
+			return nil, argsError(typ, "1 or 2", len(args))
+		}
+		env := new(EventEnvelope)
+		if len(args) == 2 {
+			if err := decode(typ, "subscription id", args[0], &env.SubscriptionID); err != nil {
+				return nil, err
+			}
+		}
+		if err := decode(typ, "event", args[len(args)-1], &env.Event); err != nil {
+			return nil, err
+		}
+		if env.Event == nil {
+			return nil, fmt.Errorf("invalid %s message: missing event", typ)
+		}
+		return env, nil
+	case TypeNotice:
+		if len(args) != 1 {
+			return nil, argsError(typ, "1", len(args))
+		}
+		env := new(NoticeEnvelope)
+		if err := decode(typ, "notice", args[0], &env.Notice); err != nil {
+			return nil, err
+		}
+		return env, nil
+	case TypeOK:
+		if len(args) != 3 {
+			return nil, argsError(typ, "3", len(args))
+		}
+		env := new(OkEnvelope)
+		if err := decode(typ, "event id", args[0], &env.EventID); err != nil {
+			return nil, err
+		}
+		if err := decode(typ, "status", args[1], &env.OK); err != nil {
+			return nil, err
+		}
+		if err := decode(typ, "reason", args[2], &env.Reason); err != nil {
+			return nil, err
+		}
+		return env, nil
+	case TypeReq:
+		if len(args) < 1 {
+			return nil, argsError(typ, "at least 1", len(args))
+		}
+		env := new(ReqEnvelope)
+		if err := decode(typ, "subscription id", args[0], &env.SubscriptionID); err != nil {
+			return nil, err
+		}
+		filters, err := decodeFilters(typ, args[1:])
+		if err != nil {
+			return nil, err
+		}
+		env.Filters = filters
+		return env, nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownType, typ)
+	}
+}
+
+// ParseMessage parses an already decoded Message into its typed Envelope.
+func ParseMessage(msg Message) (Envelope, error) {
+	data, err := msg.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// argsError returns an error for a message with the wrong number of values.
+func argsError(typ string, expect string, got int) error {
+	return fmt.Errorf("invalid %s message: expected %s values after type, got %d", typ, expect, got)
+}
+
+// decode decodes a single message value, naming the value in the error.
+func decode(typ string, name string, data json.RawMessage, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid %s message: invalid %s: %w", typ, name, err)
+	}
+	return nil
+}
+
+// decodeFilters decodes the trailing filters of "REQ" and "COUNT" messages.
+func decodeFilters(typ string, args []json.RawMessage) ([]*Filter, error) {
+	filters := make([]*Filter, len(args))
+	for i, arg := range args {
+		if err := decode(typ, "filter", arg, &filters[i]); err != nil {
+			return nil, err
+		}
+		if filters[i] == nil {
+			return nil, fmt.Errorf("invalid %s message: invalid filter: null", typ)
+		}
+	}
+	return filters, nil
+}
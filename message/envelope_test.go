@@ -0,0 +1,149 @@
+package message_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/message"
+	"github.com/go-nostr/nostr/tag"
+)
+
+func Test_Parse(t *testing.T) {
+	count := 238
+	evt := &event.Event{
+		ID:        "5020d406443aead0f7bcfbb435215dd67ecb0a1e611f8428b4a7d24f8df03ab1",
+		PubKey:    "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+		CreatedAt: 1672068534,
+		Kind:      1,
+		Tags:      []tag.Tag{},
+		Content:   "hello world",
+		Sig:       "7af707aeb8290507afdb1cbc6766b3a2696277c90e3a30a94e56bb5de951a4c91b50acb339e6b6473618d3a3563cb493e6f3b277e5283c8b7491a5da56cf561a",
+	}
+	evtJSON := `{"id":"5020d406443aead0f7bcfbb435215dd67ecb0a1e611f8428b4a7d24f8df03ab1","pubkey":"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9","created_at":1672068534,"kind":1,"tags":[],"content":"hello world","sig":"7af707aeb8290507afdb1cbc6766b3a2696277c90e3a30a94e56bb5de951a4c91b50acb339e6b6473618d3a3563cb493e6f3b277e5283c8b7491a5da56cf561a"}`
+	tests := []struct {
+		name   string
+		data   string
+		expect message.Envelope
+	}{
+		{
+			name:   "SHOULD parse AUTH challenge",
+			data:   `["AUTH","challenge"]`,
+			expect: &message.AuthEnvelope{Challenge: "challenge"},
+		},
+		{
+			name:   "SHOULD parse AUTH event",
+			data:   `["AUTH",` + evtJSON + `]`,
+			expect: &message.AuthEnvelope{Event: evt},
+		},
+		{
+			name:   "SHOULD parse CLOSE",
+			data:   `["CLOSE","sub"]`,
+			expect: &message.CloseEnvelope{SubscriptionID: "sub"},
+		},
+		{
+			name:   "SHOULD parse CLOSED",
+			data:   `["CLOSED","sub","auth-required: please authenticate"]`,
+			expect: &message.ClosedEnvelope{SubscriptionID: "sub", Reason: "auth-required: please authenticate"},
+		},
+		{
+			name:   "SHOULD parse COUNT request",
+			data:   `["COUNT","sub",{"kinds":[3],"#p":["abc"]}]`,
+			expect: &message.CountEnvelope{SubscriptionID: "sub", Filters: []*message.Filter{{Kinds: []int{3}, Tags: map[string][]string{"p": {"abc"}}}}},
+		},
+		{
+			name:   "SHOULD parse COUNT response",
+			data:   `["COUNT","sub",{"count":238}]`,
+			expect: &message.CountEnvelope{SubscriptionID: "sub", Count: &count},
+		},
+		{
+			name:   "SHOULD parse EOSE",
+			data:   `["EOSE","sub"]`,
+			expect: &message.EoseEnvelope{SubscriptionID: "sub"},
+		},
+		{
+			name:   "SHOULD parse EVENT from client",
+			data:   `["EVENT",` + evtJSON + `]`,
+			expect: &message.EventEnvelope{Event: evt},
+		},
+		{
+			name:   "SHOULD parse EVENT from relay",
+			data:   `["EVENT","sub",` + evtJSON + `]`,
+			expect: &message.EventEnvelope{SubscriptionID: "sub", Event: evt},
+		},
+		{
+			name:   "SHOULD parse NOTICE",
+			data:   `["NOTICE","hello"]`,
+			expect: &message.NoticeEnvelope{Notice: "hello"},
+		},
+		{
+			name:   "SHOULD parse OK",
+			data:   `["OK","abc",false,"blocked: no"]`,
+			expect: &message.OkEnvelope{EventID: "abc", OK: false, Reason: "blocked: no"},
+		},
+		{
+			name:   "SHOULD parse REQ",
+			data:   `["REQ","sub",{"ids":["abc"]},{"authors":["def"],"limit":10}]`,
+			expect: &message.ReqEnvelope{SubscriptionID: "sub", Filters: []*message.Filter{{IDs: []string{"abc"}}, {Authors: []string{"def"}, Limit: 10}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := message.Parse([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.expect, got) {
+				t.Fatalf("expected %+v, got %+v", tt.expect, got)
+			}
+			data, err := got.Message().Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			roundTrip, err := message.Parse(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, roundTrip) {
+				t.Errorf("expected round trip %+v, got %+v", got, roundTrip)
+			}
+			t.Logf("got %+v", got)
+		})
+	}
+}
+
+func Test_Parse_Malformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "SHOULD reject invalid JSON", data: `["EVENT"`},
+		{name: "SHOULD reject object", data: `{"type":"EVENT"}`},
+		{name: "SHOULD reject empty array", data: `[]`},
+		{name: "SHOULD reject non-string type", data: `[1,"sub"]`},
+		{name: "SHOULD reject EVENT without event", data: `["EVENT"]`},
+		{name: "SHOULD reject EVENT with null event", data: `["EVENT",null]`},
+		{name: "SHOULD reject EVENT with too many values", data: `["EVENT","sub",{},{}]`},
+		{name: "SHOULD reject REQ without subscription id", data: `["REQ"]`},
+		{name: "SHOULD reject REQ with non-object filter", data: `["REQ","sub","filter"]`},
+		{name: "SHOULD reject OK with non-boolean status", data: `["OK","abc","true","ok"]`},
+		{name: "SHOULD reject EOSE with numeric subscription id", data: `["EOSE",1]`},
+		{name: "SHOULD reject CLOSED without reason", data: `["CLOSED","sub"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := message.Parse([]byte(tt.data))
+			if err == nil {
+				t.Fatalf("expected error, got %+v", got)
+			}
+			t.Logf("got %v", err)
+		})
+	}
+	t.Run("SHOULD reject unknown type", func(t *testing.T) {
+		_, err := message.Parse([]byte(`["HELLO","world"]`))
+		if !errors.Is(err, message.ErrUnknownType) {
+			t.Errorf("expected %v, got %v", message.ErrUnknownType, err)
+		}
+	})
+}
@@ -4,8 +4,9 @@ import (
 	"github.com/go-nostr/nostr/message"
 )
 
-const Type = "EOSE"
+const Type = message.TypeEOSE
 
-func New() message.Message {
-	return message.New(Type)
+// New creates a new EoseMessage for the given subscription ID.
+func New(subscriptionID string) message.Message {
+	return message.New(Type, subscriptionID)
 }
@@ -7,12 +7,17 @@ import (
 	"github.com/go-nostr/nostr/message"
 )
 
-const Type = "EVENT"
+const Type = message.TypeEvent
 
-// New creates a new EventMessage.
-func New(subscriptionID string, evt *event.Event) message.Message {
+// New creates a new EventMessage. Clients publishing an event pass an empty
+// subscription ID, relays pass the ID of the subscription the event matched.
+// It fails when the subscription ID is longer than 64 characters.
+func New(subscriptionID string, evt *event.Event) (message.Message, error) {
 	if len(subscriptionID) > 64 {
-		panic(fmt.Errorf("invalid subscription id"))
+		return nil, fmt.Errorf("invalid subscription id")
 	}
-	return message.New(Type, subscriptionID, evt)
+	if subscriptionID == "" {
+		return message.New(Type, evt), nil
+	}
+	return message.New(Type, subscriptionID, evt), nil
 }
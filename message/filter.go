@@ -0,0 +1,219 @@
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-nostr/nostr/event"
+)
+
+// Filter is a struct that defines a set of criteria for filtering events.
+type Filter struct {
+	IDs     []string            `json:"ids,omitempty"`     // IDs specifies the event IDs, or ID prefixes, to filter.
+	Authors []string            `json:"authors,omitempty"` // Authors specifies the event authors, or author prefixes, to filter.
+	Kinds   []int               `json:"kinds,omitempty"`   // Kinds specifies the event kinds to filter.
+	Tags    map[string][]string `json:"-"`                 // Tags specifies single-letter tag values to filter by, keyed by letter and encoded as "#<letter>".
+	Since   int                 `json:"since,omitempty"`   // Since specifies the starting timestamp for filtering events.
+	Until   int                 `json:"until,omitempty"`   // Until specifies the ending timestamp for filtering events.
+	Limit   int                 `json:"limit,omitempty"`   // Limit specifies the maximum number of events to return.
+	Search  string              `json:"search,omitempty"`  // Search specifies a search term to filter events by.
+
+	// Deprecated: EventIDs is merged into the "e" tag filter. Use Tags["e"]
+	// instead.
+	EventIDs []string `json:"-"`
+	// Deprecated: PublicKeys is merged into the "p" tag filter. Use Tags["p"]
+	// instead.
+	PublicKeys []string `json:"-"`
+}
+
+// MarshalJSON encodes the Filter, writing each tag filter as a "#<letter>"
+// field.
+func (f Filter) MarshalJSON() ([]byte, error) {
+	type filter Filter
+	data, err := json.Marshal(filter(f))
+	tags := f.TagValues()
+	if err != nil || len(tags) == 0 {
+		return data, err
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	buf := bytes.NewBuffer(data[:len(data)-1])
+	for _, k := range keys {
+		if !isTagName(k) {
+			return nil, fmt.Errorf("invalid tag filter %q: must be a single letter", k)
+		}
+		values, err := json.Marshal(tags[k])
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(buf, `"#%s":%s`, k, values)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes the Filter, collecting "#<letter>" fields into Tags.
+func (f *Filter) UnmarshalJSON(data []byte) error {
+	type filter Filter
+	if err := json.Unmarshal(data, (*filter)(f)); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	f.Tags = nil
+	for k, v := range fields {
+		if !strings.HasPrefix(k, "#") {
+			continue
+		}
+		if !isTagName(k[1:]) {
+			return fmt.Errorf("invalid tag filter %q: must be a single letter", k)
+		}
+		var values []string
+		if err := json.Unmarshal(v, &values); err != nil {
+			return fmt.Errorf("invalid tag filter %q: %w", k, err)
+		}
+		if f.Tags == nil {
+			f.Tags = make(map[string][]string)
+		}
+		f.Tags[k[1:]] = values
+	}
+	return nil
+}
+
+// Matches reports whether the event satisfies every condition of the filter.
+// IDs and authors match by prefix, tag values match exactly and the search
+// term matches case-insensitively anywhere in the content.
+func (f *Filter) Matches(evt *event.Event) bool {
+	if evt == nil {
+		return false
+	}
+	if len(f.IDs) > 0 && !containsPrefix(f.IDs, evt.ID) {
+		return false
+	}
+	if len(f.Authors) > 0 && !containsPrefix(f.Authors, evt.PubKey) {
+		return false
+	}
+	if len(f.Kinds) > 0 && !containsInt(f.Kinds, evt.Kind) {
+		return false
+	}
+	if f.Since != 0 && evt.CreatedAt < f.Since {
+		return false
+	}
+	if f.Until != 0 && evt.CreatedAt > f.Until {
+		return false
+	}
+	for k, values := range f.TagValues() {
+		if !hasTag(evt, k, values) {
+			return false
+		}
+	}
+	if f.Search != "" && !strings.Contains(strings.ToLower(evt.Content), strings.ToLower(f.Search)) {
+		return false
+	}
+	return true
+}
+
+// TagValues returns the tag filters, with the deprecated EventIDs and
+// PublicKeys merged into the "e" and "p" tag filters. Stores should plan
+// their queries with it rather than with Tags.
+func (f *Filter) TagValues() map[string][]string {
+	if len(f.EventIDs) == 0 && len(f.PublicKeys) == 0 {
+		return f.Tags
+	}
+	tags := make(map[string][]string, len(f.Tags)+2)
+	for k, values := range f.Tags {
+		tags[k] = values
+	}
+	if len(f.EventIDs) > 0 {
+		tags["e"] = append(append([]string(nil), tags["e"]...), f.EventIDs...)
+	}
+	if len(f.PublicKeys) > 0 {
+		tags["p"] = append(append([]string(nil), tags["p"]...), f.PublicKeys...)
+	}
+	return tags
+}
+
+// CheckPrefixes checks that every ID and author of the filter is a hex prefix
+// of at least minPrefix characters.
+func (f *Filter) CheckPrefixes(minPrefix int) error {
+	for _, values := range [][]string{f.IDs, f.Authors} {
+		for _, v := range values {
+			if len(v) < minPrefix {
+				return fmt.Errorf("prefix %q is shorter than %d characters", v, minPrefix)
+			}
+			if len(v) > 64 || strings.Trim(strings.ToLower(v), "0123456789abcdef") != "" {
+				return fmt.Errorf("prefix %q is not a hex prefix", v)
+			}
+		}
+	}
+	return nil
+}
+
+// Filters is a list of filters, such as the filters of a "REQ" message.
+type Filters []*Filter
+
+// Match reports whether the event matches any of the filters.
+func (fs Filters) Match(evt *event.Event) bool {
+	for _, f := range fs {
+		if f != nil && f.Matches(evt) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsInt reports whether the value is in the list.
+func containsInt(list []int, v int) bool {
+	for _, i := range list {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+// containsPrefix reports whether any entry of the list is a prefix of the value.
+func containsPrefix(list []string, v string) bool {
+	for _, prefix := range list {
+		if strings.HasPrefix(v, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTag reports whether the event has a tag with the given name and one of
+// the given values.
+func hasTag(evt *event.Event, name string, values []string) bool {
+	for _, t := range evt.Tags {
+		if len(t) < 2 || t[0] != name {
+			continue
+		}
+		v, ok := t[1].(string)
+		if !ok {
+			continue
+		}
+		for _, value := range values {
+			if v == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isTagName reports whether the name is a single ASCII letter.
+func isTagName(name string) bool {
+	return len(name) == 1 && (name[0] >= 'a' && name[0] <= 'z' || name[0] >= 'A' && name[0] <= 'Z')
+}
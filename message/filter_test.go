@@ -0,0 +1,242 @@
+package message_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/message"
+	"github.com/go-nostr/nostr/tag"
+)
+
+func TestFilter_Marshal(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter *message.Filter
+		expect string
+	}{
+		{
+			name:   "SHOULD marshal empty filter",
+			filter: &message.Filter{},
+			expect: `{}`,
+		},
+		{
+			name: "SHOULD marshal tag filters with # prefix",
+			filter: &message.Filter{
+				Kinds: []int{1},
+				Tags: map[string][]string{
+					"p": {"abc"},
+					"e": {"def", "ghi"},
+				},
+			},
+			expect: `{"kinds":[1],"#e":["def","ghi"],"#p":["abc"]}`,
+		},
+		{
+			name: "SHOULD marshal only tag filters",
+			filter: &message.Filter{
+				Tags: map[string][]string{"t": {"nostr"}},
+			},
+			expect: `{"#t":["nostr"]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := message.New(tt.filter).Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != "["+tt.expect+"]" {
+				t.Errorf("expected %s, got %s", tt.expect, got)
+			}
+			var filter message.Filter
+			if err := filter.UnmarshalJSON([]byte(tt.expect)); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*tt.filter, filter) {
+				t.Errorf("expected %+v, got %+v", *tt.filter, filter)
+			}
+			t.Logf("got %s", got)
+		})
+	}
+}
+
+func TestFilter_Marshal_Deprecated(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter *message.Filter
+		expect string
+	}{
+		{
+			name:   "SHOULD marshal deprecated fields as tag filters",
+			filter: &message.Filter{EventIDs: []string{"def"}, PublicKeys: []string{"abc"}},
+			expect: `{"#e":["def"],"#p":["abc"]}`,
+		},
+		{
+			name: "SHOULD merge deprecated fields into tag filters",
+			filter: &message.Filter{
+				Tags:     map[string][]string{"e": {"def"}, "t": {"nostr"}},
+				EventIDs: []string{"ghi"},
+			},
+			expect: `{"#e":["def","ghi"],"#t":["nostr"]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags := tt.filter.Tags["e"]
+			got, err := tt.filter.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.expect {
+				t.Errorf("expected %s, got %s", tt.expect, got)
+			}
+			if !reflect.DeepEqual(tt.filter.Tags["e"], tags) {
+				t.Errorf("expected %v, got %v", tags, tt.filter.Tags["e"])
+			}
+			t.Logf("got %s", got)
+		})
+	}
+}
+
+func TestFilter_Unmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "SHOULD reject multi-letter tag filter", data: `{"#ee":["abc"]}`, wantErr: true},
+		{name: "SHOULD reject non-string tag values", data: `{"#e":[1]}`, wantErr: true},
+		{name: "SHOULD accept uppercase tag filter", data: `{"#E":["abc"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var filter message.Filter
+			err := filter.UnmarshalJSON([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+			t.Logf("got %v", err)
+		})
+	}
+}
+
+func TestFilter_Matches(t *testing.T) {
+	evt := &event.Event{
+		ID:        "5020d406443aead0f7bcfbb435215dd67ecb0a1e611f8428b4a7d24f8df03ab1",
+		PubKey:    "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+		CreatedAt: 1672068534,
+		Kind:      1,
+		Tags: []tag.Tag{
+			{"e", "7e175192220e3ebbd337b1e6eef066f05e97a418f655d86b5815b2914333bad1", "wss://nostr.example.com"},
+			{"t", "Nostr"},
+		},
+		Content: "Hello World",
+	}
+	tests := []struct {
+		name   string
+		filter *message.Filter
+		expect bool
+	}{
+		{name: "SHOULD match empty filter", filter: &message.Filter{}, expect: true},
+		{name: "SHOULD match full id", filter: &message.Filter{IDs: []string{evt.ID}}, expect: true},
+		{name: "SHOULD match id prefix", filter: &message.Filter{IDs: []string{"5020d4"}}, expect: true},
+		{name: "SHOULD not match other id", filter: &message.Filter{IDs: []string{"5020d5"}}, expect: false},
+		{name: "SHOULD match author prefix", filter: &message.Filter{Authors: []string{"0000", "f9308a"}}, expect: true},
+		{name: "SHOULD not match other author", filter: &message.Filter{Authors: []string{"0000"}}, expect: false},
+		{name: "SHOULD match kind", filter: &message.Filter{Kinds: []int{0, 1}}, expect: true},
+		{name: "SHOULD not match other kind", filter: &message.Filter{Kinds: []int{0}}, expect: false},
+		{name: "SHOULD match inclusive since", filter: &message.Filter{Since: 1672068534}, expect: true},
+		{name: "SHOULD not match later since", filter: &message.Filter{Since: 1672068535}, expect: false},
+		{name: "SHOULD match inclusive until", filter: &message.Filter{Until: 1672068534}, expect: true},
+		{name: "SHOULD not match earlier until", filter: &message.Filter{Until: 1672068533}, expect: false},
+		{
+			name:   "SHOULD match tag value",
+			filter: &message.Filter{Tags: map[string][]string{"e": {"other", "7e175192220e3ebbd337b1e6eef066f05e97a418f655d86b5815b2914333bad1"}}},
+			expect: true,
+		},
+		{
+			name:   "SHOULD match deprecated event ids",
+			filter: &message.Filter{EventIDs: []string{"7e175192220e3ebbd337b1e6eef066f05e97a418f655d86b5815b2914333bad1"}},
+			expect: true,
+		},
+		{
+			name:   "SHOULD not match other deprecated public keys",
+			filter: &message.Filter{PublicKeys: []string{"abc"}},
+			expect: false,
+		},
+		{
+			name:   "SHOULD not match tag value prefix",
+			filter: &message.Filter{Tags: map[string][]string{"e": {"7e1751"}}},
+			expect: false,
+		},
+		{
+			name:   "SHOULD require every tag filter",
+			filter: &message.Filter{Tags: map[string][]string{"t": {"Nostr"}, "p": {"abc"}}},
+			expect: false,
+		},
+		{
+			name:   "SHOULD match tag values case-sensitively",
+			filter: &message.Filter{Tags: map[string][]string{"t": {"nostr"}}},
+			expect: false,
+		},
+		{name: "SHOULD match search term", filter: &message.Filter{Search: "hello"}, expect: true},
+		{name: "SHOULD not match missing search term", filter: &message.Filter{Search: "bye"}, expect: false},
+		{
+			name:   "SHOULD require every condition",
+			filter: &message.Filter{Kinds: []int{1}, Authors: []string{"f9308a"}, Until: 1},
+			expect: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.filter.Matches(evt)
+			if got != tt.expect {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestFilters_Match(t *testing.T) {
+	evt := &event.Event{Kind: 1}
+	tests := []struct {
+		name    string
+		filters message.Filters
+		expect  bool
+	}{
+		{name: "SHOULD not match without filters", filters: message.Filters{}, expect: false},
+		{name: "SHOULD match any filter", filters: message.Filters{{Kinds: []int{0}}, {Kinds: []int{1}}}, expect: true},
+		{name: "SHOULD not match when no filter matches", filters: message.Filters{{Kinds: []int{0}}, {Kinds: []int{3}}}, expect: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.filters.Match(evt)
+			if got != tt.expect {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestFilter_CheckPrefixes(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    *message.Filter
+		minPrefix int
+		wantErr   bool
+	}{
+		{name: "SHOULD accept long enough prefixes", filter: &message.Filter{IDs: []string{"abcd"}, Authors: []string{"0123"}}, minPrefix: 4},
+		{name: "SHOULD reject short id prefix", filter: &message.Filter{IDs: []string{"abc"}}, minPrefix: 4, wantErr: true},
+		{name: "SHOULD reject short author prefix", filter: &message.Filter{Authors: []string{"abc"}}, minPrefix: 4, wantErr: true},
+		{name: "SHOULD reject non-hex prefix", filter: &message.Filter{IDs: []string{"xyz0"}}, minPrefix: 4, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.CheckPrefixes(tt.minPrefix)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+			t.Logf("got %v", err)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package message
+
+import (
+	"fmt"
+	"sync"
+)
+
+// EnvelopeHandler is an interface for handling parsed Envelope types.
+type EnvelopeHandler interface {
+	HandleEnvelope(env Envelope)
+}
+
+// EnvelopeHandlerFunc is a function type that takes an Envelope as a parameter.
+type EnvelopeHandlerFunc func(env Envelope)
+
+// HandleEnvelope calls the EnvelopeHandlerFunc with the provided Envelope.
+func (f EnvelopeHandlerFunc) HandleEnvelope(env Envelope) {
+	f(env)
+}
+
+// NewServeMux creates a new ServeMux without any routes. Errors are discarded
+// until an error handler is registered with HandleErrorFunc.
+func NewServeMux() *ServeMux {
+	return &ServeMux{
+		errFn:    func(err error) {},
+		handlers: make(map[string]EnvelopeHandler),
+	}
+}
+
+// ServeMux is a Handler that parses each Message and routes the resulting
+// Envelope to the handler registered for its type.
+type ServeMux struct {
+	errFn    func(error)
+	handlers map[string]EnvelopeHandler
+	mu       sync.RWMutex
+}
+
+// Handle parses the Message and dispatches it to the handler registered for
+// its type. Messages that fail to parse or have no registered handler are
+// reported to the error handler.
+func (mux *ServeMux) Handle(mess Message) {
+	env, err := ParseMessage(mess)
+	if err != nil {
+		mux.errFn(err)
+		return
+	}
+	mux.HandleEnvelope(env)
+}
+
+// HandleEnvelope dispatches an already parsed Envelope to the handler
+// registered for its type.
+func (mux *ServeMux) HandleEnvelope(env Envelope) {
+	mux.mu.RLock()
+	h, ok := mux.handlers[env.Type()]
+	mux.mu.RUnlock()
+	if !ok {
+		mux.errFn(fmt.Errorf("no handler registered for %s message", env.Type()))
+		return
+	}
+	h.HandleEnvelope(env)
+}
+
+// HandleErrorFunc sets the function to be called when a message cannot be
+// parsed or routed.
+func (mux *ServeMux) HandleErrorFunc(fn func(error)) {
+	mux.errFn = fn
+}
+
+// Route registers the handler for the given message type, replacing any
+// previously registered handler.
+func (mux *ServeMux) Route(typ string, h EnvelopeHandler) {
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	mux.handlers[typ] = h
+}
+
+// RouteFunc registers the handler function for the given message type.
+func (mux *ServeMux) RouteFunc(typ string, fn func(env Envelope)) {
+	mux.Route(typ, EnvelopeHandlerFunc(fn))
+}
@@ -0,0 +1,64 @@
+package message_test
+
+import (
+	"testing"
+
+	"github.com/go-nostr/nostr/message"
+)
+
+func TestServeMux_Handle(t *testing.T) {
+	tests := []struct {
+		name    string
+		mess    message.Message
+		expect  string
+		wantErr bool
+	}{
+		{
+			name:   "SHOULD route NOTICE message to NOTICE handler",
+			mess:   message.New("NOTICE", "hello"),
+			expect: message.TypeNotice,
+		},
+		{
+			name:   "SHOULD route EOSE message to EOSE handler",
+			mess:   message.New("EOSE", "sub"),
+			expect: message.TypeEOSE,
+		},
+		{
+			name:    "SHOULD report message without handler",
+			mess:    message.New("OK", "abc", true, ""),
+			wantErr: true,
+		},
+		{
+			name:    "SHOULD report malformed message",
+			mess:    message.New("EOSE"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				got string
+				err error
+			)
+			mux := message.NewServeMux()
+			mux.HandleErrorFunc(func(e error) {
+				err = e
+			})
+			mux.RouteFunc(message.TypeNotice, func(env message.Envelope) {
+				got = env.(*message.NoticeEnvelope).Type()
+			})
+			mux.RouteFunc(message.TypeEOSE, func(env message.Envelope) {
+				got = env.(*message.EoseEnvelope).Type()
+			})
+			var h message.Handler = mux
+			h.Handle(tt.mess)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.expect {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+			t.Logf("got %v", got)
+		})
+	}
+}
@@ -2,9 +2,9 @@ package noticemessage
 
 import "github.com/go-nostr/nostr/message"
 
-const Type = "NOTICE"
+const Type = message.TypeNotice
 
-// New creates a new NoticeMessage.
-func New() message.Message {
-	return message.New(Type)
+// New creates a new NoticeMessage with a human-readable message.
+func New(notice string) message.Message {
+	return message.New(Type, notice)
 }
@@ -1,10 +1,42 @@
 package okmessage
 
-import "github.com/go-nostr/nostr/message"
+import (
+	"strings"
 
-const Type = "OK"
+	"github.com/go-nostr/nostr/message"
+)
+
+const Type = message.TypeOK
+
+// Machine-readable prefixes of the status of rejected events, as defined by
+// NIP-01, NIP-20 and NIP-42.
+const (
+	PrefixAuthRequired = "auth-required"
+	PrefixBlocked      = "blocked"
+	PrefixDuplicate    = "duplicate"
+	PrefixError        = "error"
+	PrefixInvalid      = "invalid"
+	PrefixPoW          = "pow"
+	PrefixRateLimited  = "rate-limited"
+	PrefixRestricted   = "restricted"
+)
 
 // New creates a new OkMessage.
 func New(eventID string, ok bool, status string) message.Message {
 	return message.New(Type, eventID, ok, status)
 }
+
+// Reason formats a status with the given prefix, such as "blocked: spam".
+func Reason(prefix string, msg string) string {
+	return prefix + ": " + msg
+}
+
+// ParseReason splits a status into its machine-readable prefix and the human
+// readable message. The prefix is empty if the status has none.
+func ParseReason(status string) (prefix string, msg string) {
+	prefix, msg, ok := strings.Cut(status, ":")
+	if !ok || prefix == "" || strings.ContainsAny(prefix, " \t") {
+		return "", status
+	}
+	return prefix, strings.TrimSpace(msg)
+}
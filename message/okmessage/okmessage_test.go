@@ -40,3 +40,52 @@ func Test_New(t *testing.T) {
 		})
 	}
 }
+
+func Test_Reason(t *testing.T) {
+	got := okmessage.Reason(okmessage.PrefixBlocked, "spam")
+	if got != "blocked: spam" {
+		t.Errorf("expected %v, got %v", "blocked: spam", got)
+	}
+}
+
+func Test_ParseReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		prefix string
+		msg    string
+	}{
+		{
+			name:   "SHOULD parse prefix and message",
+			status: "rate-limited: slow down",
+			prefix: okmessage.PrefixRateLimited,
+			msg:    "slow down",
+		},
+		{
+			name:   "SHOULD parse prefix without message",
+			status: "duplicate:",
+			prefix: okmessage.PrefixDuplicate,
+			msg:    "",
+		},
+		{
+			name:   "SHOULD return message without prefix",
+			status: "something went wrong: disk full",
+			prefix: "",
+			msg:    "something went wrong: disk full",
+		},
+		{
+			name:   "SHOULD return empty status",
+			status: "",
+			prefix: "",
+			msg:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix, msg := okmessage.ParseReason(tt.status)
+			if prefix != tt.prefix || msg != tt.msg {
+				t.Errorf("expected %q %q, got %q %q", tt.prefix, tt.msg, prefix, msg)
+			}
+		})
+	}
+}
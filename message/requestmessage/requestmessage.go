@@ -4,7 +4,7 @@ import (
 	"github.com/go-nostr/nostr/message"
 )
 
-const Type = "REQ"
+const Type = message.TypeReq
 
 // New creates a new RequestMessage with the provided subscription ID and filters.
 func New(sid string, filter ...*Filter) message.Message {
@@ -1 +1,70 @@
+// Package naddr encodes and decodes NIP-19 "naddr" entities, which point to a
+// parameterized replaceable event by its identifier, kind and author.
 package naddr
+
+import (
+	"fmt"
+
+	"github.com/go-nostr/nostr/internal/tlv"
+)
+
+// Prefix is the human-readable part of naddr entities.
+const Prefix = "naddr"
+
+// Address is an event coordinate.
+type Address struct {
+	Identifier string   // Identifier is the "d" tag of the event.
+	Kind       int      // Kind is the kind of the event.
+	PubKey     string   // PubKey is the hex encoded public key of the author.
+	Relays     []string // Relays are URLs of relays where the event may be found.
+}
+
+// Decode decodes the naddr entity.
+func Decode(naddr string) (*Address, error) {
+	entries, err := tlv.Decode(Prefix, naddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid naddr: %w", err)
+	}
+	addr := &Address{}
+	var hasIdentifier, hasKind bool
+	for _, e := range entries {
+		switch e.Type {
+		case tlv.TypeSpecial:
+			addr.Identifier, hasIdentifier = string(e.Value), true
+		case tlv.TypeRelay:
+			addr.Relays = append(addr.Relays, string(e.Value))
+		case tlv.TypeAuthor:
+			addr.PubKey, err = tlv.ParseHex32(e)
+		case tlv.TypeKind:
+			addr.Kind, err = tlv.ParseKind(e)
+			hasKind = true
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid naddr: %w", err)
+		}
+	}
+	switch {
+	case !hasIdentifier:
+		return nil, fmt.Errorf("invalid naddr: missing identifier")
+	case addr.PubKey == "":
+		return nil, fmt.Errorf("invalid naddr: missing author")
+	case !hasKind:
+		return nil, fmt.Errorf("invalid naddr: missing kind")
+	}
+	return addr, nil
+}
+
+// Encode encodes the address as an naddr entity.
+func Encode(addr *Address) (string, error) {
+	author, err := tlv.Hex32(tlv.TypeAuthor, addr.PubKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %w", err)
+	}
+	kind, err := tlv.Kind(addr.Kind)
+	if err != nil {
+		return "", err
+	}
+	entries := []tlv.Entry{{Type: tlv.TypeSpecial, Value: []byte(addr.Identifier)}}
+	entries = append(entries, tlv.Relays(addr.Relays)...)
+	return tlv.Encode(Prefix, append(entries, author, kind))
+}
@@ -0,0 +1,130 @@
+package naddr_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-nostr/nostr/internal/tlv"
+	"github.com/go-nostr/nostr/naddr"
+)
+
+func Test_EncodeDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		addr *naddr.Address
+	}{
+		{
+			name: "SHOULD round-trip address",
+			addr: &naddr.Address{
+				Identifier: "my-article",
+				Kind:       30023,
+				PubKey:     "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d",
+				Relays:     []string{"wss://relay.example.com"},
+			},
+		},
+		{
+			name: "SHOULD round-trip address with empty identifier",
+			addr: &naddr.Address{
+				Kind:   10000,
+				PubKey: "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := naddr.Encode(tt.addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := naddr.Decode(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.addr, got) {
+				t.Errorf("expected %+v, got %+v", tt.addr, got)
+			}
+		})
+	}
+}
+
+// Test_Vectors checks naddr entities produced by nbd-wtf/go-nostr.
+func Test_Vectors(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		addr *naddr.Address
+	}{
+		{
+			name: "SHOULD match address with relays vector",
+			s:    "naddr1qqrxyctwv9hxzqfwwaehxw309aex2mrp0yhxummnw3ezuetcv9khqmr99ekhjer0d4skjm3wv4uxzmtsd3jjucm0d5q3vamnwvaz7tmwdaehgu3wvfskuctwvyhxxmmdqgsrhuxx8l9ex335q7he0f09aej04zpazpl0ne2cgukyawd24mayt8grqsqqqa28a3lkds",
+			addr: &naddr.Address{
+				Identifier: "banana",
+				Kind:       30023,
+				PubKey:     "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d",
+				Relays:     []string{"wss://relay.nostr.example.mydomain.example.com", "wss://nostr.banana.com"},
+			},
+		},
+		{
+			name: "SHOULD match address without relays vector",
+			s:    "naddr1qq98yetxv4ex2mnrv4esygrl54h466tz4v0re4pyuavvxqptsejl0vxcmnhfl60z3rth2xkpjspsgqqqw4rsf34vl5",
+			addr: &naddr.Address{
+				Identifier: "references",
+				Kind:       30023,
+				PubKey:     "7fa56f5d6962ab1e3cd424e758c3002b8665f7b0d8dcee9fe9e288d7751ac194",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := naddr.Decode(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.addr, got) {
+				t.Errorf("expected %+v, got %+v", tt.addr, got)
+			}
+			s, err := naddr.Encode(tt.addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s != tt.s {
+				t.Errorf("expected %v, got %v", tt.s, s)
+			}
+		})
+	}
+}
+
+func Test_Decode(t *testing.T) {
+	missingKind, err := tlv.Encode(naddr.Prefix, []tlv.Entry{
+		{Type: tlv.TypeSpecial, Value: []byte("my-article")},
+		{Type: tlv.TypeAuthor, Value: make([]byte, 32)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		s    string
+		err  error
+	}{
+		{
+			name: "SHOULD reject other prefix",
+			s:    "npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjptg",
+			err:  tlv.ErrPrefix,
+		},
+		{
+			name: "SHOULD reject address without kind",
+			s:    missingKind,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := naddr.Decode(tt.s)
+			t.Logf("got %v", err)
+			if err == nil || (tt.err != nil && !errors.Is(err, tt.err)) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
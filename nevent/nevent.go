@@ -0,0 +1,74 @@
+// Package nevent encodes and decodes NIP-19 "nevent" entities, which point to
+// an event with relays where it may be found, its author and its kind.
+package nevent
+
+import (
+	"fmt"
+
+	"github.com/go-nostr/nostr/internal/tlv"
+)
+
+// Prefix is the human-readable part of nevent entities.
+const Prefix = "nevent"
+
+// Event is an event pointer. Author and Kind are optional.
+type Event struct {
+	ID     string   // ID is the hex encoded ID of the event.
+	Relays []string // Relays are URLs of relays where the event may be found.
+	Author string   // Author is the hex encoded public key of the author, or empty.
+	Kind   *int     // Kind is the kind of the event, or nil.
+}
+
+// Decode decodes the nevent entity.
+func Decode(nevent string) (*Event, error) {
+	entries, err := tlv.Decode(Prefix, nevent)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nevent: %w", err)
+	}
+	evt := &Event{}
+	for _, e := range entries {
+		switch e.Type {
+		case tlv.TypeSpecial:
+			evt.ID, err = tlv.ParseHex32(e)
+		case tlv.TypeRelay:
+			evt.Relays = append(evt.Relays, string(e.Value))
+		case tlv.TypeAuthor:
+			evt.Author, err = tlv.ParseHex32(e)
+		case tlv.TypeKind:
+			var kind int
+			kind, err = tlv.ParseKind(e)
+			evt.Kind = &kind
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid nevent: %w", err)
+		}
+	}
+	if evt.ID == "" {
+		return nil, fmt.Errorf("invalid nevent: missing event id")
+	}
+	return evt, nil
+}
+
+// Encode encodes the event pointer as an nevent entity.
+func Encode(evt *Event) (string, error) {
+	id, err := tlv.Hex32(tlv.TypeSpecial, evt.ID)
+	if err != nil {
+		return "", fmt.Errorf("invalid event id: %w", err)
+	}
+	entries := append([]tlv.Entry{id}, tlv.Relays(evt.Relays)...)
+	if evt.Author != "" {
+		author, err := tlv.Hex32(tlv.TypeAuthor, evt.Author)
+		if err != nil {
+			return "", fmt.Errorf("invalid author: %w", err)
+		}
+		entries = append(entries, author)
+	}
+	if evt.Kind != nil {
+		kind, err := tlv.Kind(*evt.Kind)
+		if err != nil {
+			return "", err
+		}
+		entries = append(entries, kind)
+	}
+	return tlv.Encode(Prefix, entries)
+}
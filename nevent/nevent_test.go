@@ -0,0 +1,129 @@
+package nevent_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/go-nostr/nostr/internal/tlv"
+	"github.com/go-nostr/nostr/nevent"
+)
+
+func Test_EncodeDecode(t *testing.T) {
+	kind := 1
+	tests := []struct {
+		name string
+		evt  *nevent.Event
+	}{
+		{
+			name: "SHOULD round-trip event id",
+			evt:  &nevent.Event{ID: "b9f5441e45ca39179320e0031cfb18e34078673dcc3d3e3a3b3a981760aa5696"},
+		},
+		{
+			name: "SHOULD round-trip event id, relays, author and kind",
+			evt: &nevent.Event{
+				ID:     "b9f5441e45ca39179320e0031cfb18e34078673dcc3d3e3a3b3a981760aa5696",
+				Relays: []string{"wss://nostr-relay.untethr.me", "wss://nostr-pub.wellorder.net"},
+				Author: "46fcbe3065eaf1ae7811465924e48923363ff3f526bd6f73d7c184b16bd8ce4d",
+				Kind:   &kind,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := nevent.Encode(tt.evt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := nevent.Decode(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.evt, got) {
+				t.Errorf("expected %+v, got %+v", tt.evt, got)
+			}
+		})
+	}
+}
+
+// Test_Vectors checks nevent entities produced by nbd-wtf/go-nostr.
+func Test_Vectors(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		evt  *nevent.Event
+	}{
+		{
+			name: "SHOULD match event id, relay and author vector",
+			s:    "nevent1qqsy2vn0t45k92c78n2zfe6ccvqzhpn977cd3h8wnl579zxhw5dvr9qpzpmhxue69uhkyctwv9hxztnrdaksygrl54h466tz4v0re4pyuavvxqptsejl0vxcmnhfl60z3rth2x4m3q04ndyp",
+			evt: &nevent.Event{
+				ID:     "45326f5d6962ab1e3cd424e758c3002b8665f7b0d8dcee9fe9e288d7751ac194",
+				Relays: []string{"wss://banana.com"},
+				Author: "7fa56f5d6962ab1e3cd424e758c3002b8665f7b0d8dcee9fe9e288d7751abb88",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nevent.Decode(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.evt, got) {
+				t.Errorf("expected %+v, got %+v", tt.evt, got)
+			}
+			s, err := nevent.Encode(tt.evt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s != tt.s {
+				t.Errorf("expected %v, got %v", tt.s, s)
+			}
+		})
+	}
+}
+
+func Test_Decode(t *testing.T) {
+	encode := func(data ...byte) string {
+		grp, err := bech32.ConvertBits(data, 8, 5, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := bech32.Encode(nevent.Prefix, grp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	tests := []struct {
+		name string
+		s    string
+		err  error
+	}{
+		{
+			name: "SHOULD reject other prefix",
+			s:    "nprofile1qqsrhuxx8l9ex335q7he0f09aej04zpazpl0ne2cgukyawd24mayt8gpp4mhxue69uhhytnc9e3k7mgpz4mhxue69uhkg6nzv9ejuumpv34kytnrdaksjlyr9p",
+			err:  tlv.ErrPrefix,
+		},
+		{
+			name: "SHOULD reject truncated tlv",
+			s:    encode(0, 32, 0xb9, 0xf5),
+			err:  tlv.ErrTruncated,
+		},
+		{
+			name: "SHOULD reject truncated tlv header",
+			s:    encode(1),
+			err:  tlv.ErrTruncated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := nevent.Decode(tt.s)
+			t.Logf("got %v", err)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Package nip04 encrypts and decrypts the content of direct messages as
+// described in NIP-04: AES-256-CBC keyed by the secp256k1 ECDH shared secret
+// of the sender and the recipient.
+package nip04
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-nostr/nostr/keys"
+)
+
+// separator separates the ciphertext from the IV in the encrypted content.
+const separator = "?iv="
+
+// ErrContent is returned when the encrypted content is malformed or can not
+// be decrypted with the key.
+var ErrContent = errors.New("invalid encrypted content")
+
+// SharedSecret computes the ECDH shared secret of the hex encoded private key
+// and the hex encoded x-only public key of the other party. Both parties
+// compute the same secret, which is the x coordinate of the shared point.
+func SharedSecret(prvKeyHex string, pubKeyHex string) ([]byte, error) {
+	prvKey, err := keys.ParsePrivateKey(prvKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	defer prvKey.Zero()
+	pubKey, err := keys.ParsePublicKey(pubKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	return prvKey.SharedSecret(pubKey)
+}
+
+// Encrypt encrypts the plaintext from the private key to the public key and
+// returns the content of a direct message.
+func Encrypt(prvKeyHex string, pubKeyHex string, plaintext string) (string, error) {
+	key, err := SharedSecret(prvKeyHex, pubKeyHex)
+	if err != nil {
+		return "", err
+	}
+	defer keys.Wipe(key)
+	return EncryptWithKey(key, plaintext, rand.Reader)
+}
+
+// Decrypt decrypts the content of a direct message exchanged between the
+// private key and the public key.
+func Decrypt(prvKeyHex string, pubKeyHex string, content string) (string, error) {
+	key, err := SharedSecret(prvKeyHex, pubKeyHex)
+	if err != nil {
+		return "", err
+	}
+	defer keys.Wipe(key)
+	return DecryptWithKey(key, content)
+}
+
+// EncryptWithKey encrypts the plaintext with the shared secret and an IV read
+// from random, and formats it as "<ciphertext>?iv=<iv>" in base64.
+func EncryptWithKey(key []byte, plaintext string, random io.Reader) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(random, iv); err != nil {
+		return "", fmt.Errorf("unable to generate iv: %w", err)
+	}
+	data := pad([]byte(plaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+	return base64.StdEncoding.EncodeToString(data) + separator + base64.StdEncoding.EncodeToString(iv), nil
+}
+
+// DecryptWithKey decrypts content formatted as "<ciphertext>?iv=<iv>" with the
+// shared secret.
+func DecryptWithKey(key []byte, content string) (string, error) {
+	ct, iv, ok := strings.Cut(content, separator)
+	if !ok {
+		return "", fmt.Errorf("%w: missing iv", ErrContent)
+	}
+	data, err := base64.StdEncoding.DecodeString(ct)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrContent, err)
+	}
+	ivData, err := base64.StdEncoding.DecodeString(iv)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrContent, err)
+	}
+	if len(ivData) != aes.BlockSize {
+		return "", fmt.Errorf("%w: expected %d bytes iv, got %d", ErrContent, aes.BlockSize, len(ivData))
+	}
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return "", fmt.Errorf("%w: ciphertext is not a multiple of the block size", ErrContent)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	cipher.NewCBCDecrypter(block, ivData).CryptBlocks(data, data)
+	plaintext, err := unpad(data)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// pad appends PKCS#7 padding to the data.
+func pad(data []byte) []byte {
+	n := aes.BlockSize - len(data)%aes.BlockSize
+	return append(data, bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+// unpad removes the PKCS#7 padding of the data.
+func unpad(data []byte) ([]byte, error) {
+	n := int(data[len(data)-1])
+	if n == 0 || n > aes.BlockSize || n > len(data) {
+		return nil, fmt.Errorf("%w: invalid padding", ErrContent)
+	}
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, fmt.Errorf("%w: invalid padding", ErrContent)
+		}
+	}
+	return data[:len(data)-n], nil
+}
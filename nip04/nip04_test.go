@@ -0,0 +1,150 @@
+package nip04_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/go-nostr/nostr/nip04"
+)
+
+const (
+	prvKey1 = "0000000000000000000000000000000000000000000000000000000000000001"
+	pubKey1 = "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	prvKey2 = "0000000000000000000000000000000000000000000000000000000000000002"
+	pubKey2 = "c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"
+	// shared is the x coordinate of 2·G, shared by keys 1 and 2.
+	shared = "c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"
+)
+
+func TestSharedSecret(t *testing.T) {
+	tests := []struct {
+		name      string
+		prvKeyHex string
+		pubKeyHex string
+		expect    string
+		err       bool
+	}{
+		{
+			name:      "SHOULD compute shared secret of sender",
+			prvKeyHex: prvKey1,
+			pubKeyHex: pubKey2,
+			expect:    shared,
+		},
+		{
+			name:      "SHOULD compute shared secret of recipient",
+			prvKeyHex: prvKey2,
+			pubKeyHex: pubKey1,
+			expect:    shared,
+		},
+		{
+			name:      "SHOULD reject zero private key",
+			prvKeyHex: "0000000000000000000000000000000000000000000000000000000000000000",
+			pubKeyHex: pubKey1,
+			err:       true,
+		},
+		{
+			name:      "SHOULD reject invalid public key",
+			prvKeyHex: prvKey1,
+			pubKeyHex: "79be667e",
+			err:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nip04.SharedSecret(tt.prvKeyHex, tt.pubKeyHex)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if err == nil && tt.expect != hex.EncodeToString(got) {
+				t.Errorf("expected %v, got %x", tt.expect, got)
+			}
+			t.Logf("got %x", got)
+		})
+	}
+}
+
+func TestEncryptWithKey(t *testing.T) {
+	key, _ := hex.DecodeString(shared)
+	iv := bytes.NewReader([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15})
+	got, err := nip04.EncryptWithKey(key, "nostr is cool", iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "fNPrdIzdCUL567ks/6uZOA==?iv=AAECAwQFBgcICQoLDA0ODw=="
+	if expect != got {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+	t.Logf("got %v", got)
+}
+
+func TestDecrypt(t *testing.T) {
+	content, err := nip04.Encrypt(prvKey1, pubKey2, "nostr is cool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		prvKeyHex string
+		pubKeyHex string
+		content   string
+		expect    string
+		err       error
+	}{
+		{
+			name:      "SHOULD decrypt as recipient",
+			prvKeyHex: prvKey2,
+			pubKeyHex: pubKey1,
+			content:   content,
+			expect:    "nostr is cool",
+		},
+		{
+			name:      "SHOULD decrypt as sender",
+			prvKeyHex: prvKey1,
+			pubKeyHex: pubKey2,
+			content:   content,
+			expect:    "nostr is cool",
+		},
+		{
+			name:      "SHOULD decrypt known content",
+			prvKeyHex: prvKey2,
+			pubKeyHex: pubKey1,
+			content:   "fNPrdIzdCUL567ks/6uZOA==?iv=AAECAwQFBgcICQoLDA0ODw==",
+			expect:    "nostr is cool",
+		},
+		{
+			name:      "SHOULD reject content without iv",
+			prvKeyHex: prvKey2,
+			pubKeyHex: pubKey1,
+			content:   "fNPrdIzdCUL567ks/6uZOA==",
+			err:       nip04.ErrContent,
+		},
+		{
+			name:      "SHOULD reject short iv",
+			prvKeyHex: prvKey2,
+			pubKeyHex: pubKey1,
+			content:   "fNPrdIzdCUL567ks/6uZOA==?iv=AAECAw==",
+			err:       nip04.ErrContent,
+		},
+		{
+			name:      "SHOULD reject truncated ciphertext",
+			prvKeyHex: prvKey2,
+			pubKeyHex: pubKey1,
+			content:   "fNPrdIzdCUL5?iv=AAECAwQFBgcICQoLDA0ODw==",
+			err:       nip04.ErrContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nip04.Decrypt(tt.prvKeyHex, tt.pubKeyHex, tt.content)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if tt.expect != got {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+			t.Logf("got %v", got)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Package nip19 decodes any NIP-19 bech32 entity by dispatching on its
+// human-readable prefix.
+package nip19
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/go-nostr/nostr/naddr"
+	"github.com/go-nostr/nostr/nevent"
+	"github.com/go-nostr/nostr/nprofile"
+	"github.com/go-nostr/nostr/nrelay"
+)
+
+// Prefixes of the NIP-19 entities.
+const (
+	PrefixNaddr    = naddr.Prefix
+	PrefixNevent   = nevent.Prefix
+	PrefixNote     = "note"
+	PrefixNprofile = nprofile.Prefix
+	PrefixNpub     = "npub"
+	PrefixNrelay   = nrelay.Prefix
+	PrefixNsec     = "nsec"
+)
+
+// ErrUnknownPrefix is returned when decoding an entity with a prefix that is
+// not defined by NIP-19.
+var ErrUnknownPrefix = errors.New("unknown nip19 prefix")
+
+// Decode decodes the entity according to its prefix. The value is the hex
+// encoded key or event ID of "npub", "nsec" and "note" entities, the relay URL
+// of "nrelay" entities, a *nprofile.Profile, a *nevent.Event or a
+// *naddr.Address.
+func Decode(s string) (prefix string, value any, err error) {
+	prefix, grp, err := bech32.DecodeNoLimit(s)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid nip19 entity: %w", err)
+	}
+	switch prefix {
+	case PrefixNaddr:
+		value, err = naddr.Decode(s)
+	case PrefixNevent:
+		value, err = nevent.Decode(s)
+	case PrefixNote, PrefixNpub, PrefixNsec:
+		value, err = decodeHex32(prefix, grp)
+	case PrefixNprofile:
+		value, err = nprofile.Decode(s)
+	case PrefixNrelay:
+		value, err = nrelay.Decode(s)
+	default:
+		return "", nil, fmt.Errorf("%w %q", ErrUnknownPrefix, prefix)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return prefix, value, nil
+}
+
+// decodeHex32 decodes the data of the entity with the prefix holding a 32
+// bytes key or event ID into its hex encoding.
+func decodeHex32(prefix string, grp []byte) (string, error) {
+	data, err := bech32.ConvertBits(grp, 5, 8, false)
+	if err != nil || len(data) != 32 {
+		return "", fmt.Errorf("invalid %s", prefix)
+	}
+	return hex.EncodeToString(data), nil
+}
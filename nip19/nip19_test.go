@@ -0,0 +1,96 @@
+package nip19_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/go-nostr/nostr/nip19"
+	"github.com/go-nostr/nostr/nprofile"
+)
+
+func encode(t *testing.T, prefix string, data []byte) string {
+	t.Helper()
+	grp, err := bech32.ConvertBits(data, 8, 5, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := bech32.Encode(prefix, grp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func Test_Decode(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      string
+		prefix string
+		value  any
+		err    bool
+	}{
+		{
+			name:   "SHOULD decode npub",
+			s:      "npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjptg",
+			prefix: nip19.PrefixNpub,
+			value:  "7e7e9c42a91bfef19fa929e5fda1b72e0ebc1a4c1141673e2794234d86addf4e",
+		},
+		{
+			name:   "SHOULD decode nsec",
+			s:      "nsec1vl029mgpspedva04g90vltkh6fvh240zqtv9k0t9af8935ke9laqsnlfe5",
+			prefix: nip19.PrefixNsec,
+			value:  "67dea2ed018072d675f5415ecfaed7d2597555e202d85b3d65ea4e58d2d92ffa",
+		},
+		{
+			name:   "SHOULD decode note",
+			s:      encode(t, "note", make([]byte, 32)),
+			prefix: nip19.PrefixNote,
+			value:  strings.Repeat("0", 64),
+		},
+		{
+			name:   "SHOULD decode nprofile",
+			s:      "nprofile1qqsrhuxx8l9ex335q7he0f09aej04zpazpl0ne2cgukyawd24mayt8gpp4mhxue69uhhytnc9e3k7mgpz4mhxue69uhkg6nzv9ejuumpv34kytnrdaksjlyr9p",
+			prefix: nip19.PrefixNprofile,
+			value: &nprofile.Profile{
+				PubKey: "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d",
+				Relays: []string{"wss://r.x.com", "wss://djbas.sadkb.com"},
+			},
+		},
+		{
+			name: "SHOULD reject invalid checksum",
+			s:    "npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjpta",
+			err:  true,
+		},
+		{
+			name: "SHOULD reject npub of wrong length",
+			s:    encode(t, "npub", make([]byte, 16)),
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix, value, err := nip19.Decode(tt.s)
+			t.Logf("got %v, %+v, %v", prefix, value, err)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if prefix != tt.prefix {
+				t.Errorf("expected %v, got %v", tt.prefix, prefix)
+			}
+			if !reflect.DeepEqual(tt.value, value) {
+				t.Errorf("expected %+v, got %+v", tt.value, value)
+			}
+		})
+	}
+}
+
+func Test_Decode_UnknownPrefix(t *testing.T) {
+	_, _, err := nip19.Decode(encode(t, "lnbc", make([]byte, 32)))
+	t.Logf("got %v", err)
+	if !errors.Is(err, nip19.ErrUnknownPrefix) {
+		t.Errorf("expected %v, got %v", nip19.ErrUnknownPrefix, err)
+	}
+}
@@ -0,0 +1,49 @@
+// Package nip21 parses and formats "nostr:" URIs of NIP-21.
+package nip21
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-nostr/nostr/nip19"
+)
+
+// Scheme is the scheme of nostr URIs.
+const Scheme = "nostr"
+
+// ErrPrivateKey is returned for URIs of "nsec" entities, which NIP-21 forbids.
+var ErrPrivateKey = errors.New("nostr uri must not contain a private key")
+
+// Format returns the nostr URI of the NIP-19 entity.
+func Format(entity string) (string, error) {
+	if _, _, err := decode(entity); err != nil {
+		return "", err
+	}
+	return Scheme + ":" + entity, nil
+}
+
+// Parse decodes the NIP-19 entity of the nostr URI, as nip19.Decode does. The
+// scheme is optional, so that bare entities pasted by users are accepted too.
+func Parse(uri string) (prefix string, value any, err error) {
+	entity := strings.TrimSpace(uri)
+	if scheme, rest, ok := strings.Cut(entity, ":"); ok {
+		if !strings.EqualFold(scheme, Scheme) {
+			return "", nil, fmt.Errorf("invalid nostr uri: unsupported scheme %q", scheme)
+		}
+		entity = strings.TrimPrefix(rest, "//")
+	}
+	return decode(entity)
+}
+
+// decode decodes the entity, rejecting private keys.
+func decode(entity string) (string, any, error) {
+	prefix, value, err := nip19.Decode(entity)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid nostr uri: %w", err)
+	}
+	if prefix == nip19.PrefixNsec {
+		return "", nil, ErrPrivateKey
+	}
+	return prefix, value, nil
+}
@@ -0,0 +1,103 @@
+package nip21_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-nostr/nostr/nip19"
+	"github.com/go-nostr/nostr/nip21"
+)
+
+const (
+	testNpub = "npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjptg"
+	testNsec = "nsec1vl029mgpspedva04g90vltkh6fvh240zqtv9k0t9af8935ke9laqsnlfe5"
+)
+
+func Test_Parse(t *testing.T) {
+	tests := []struct {
+		name   string
+		uri    string
+		prefix string
+		err    bool
+		errIs  error
+	}{
+		{
+			name:   "SHOULD parse nostr uri",
+			uri:    "nostr:" + testNpub,
+			prefix: nip19.PrefixNpub,
+		},
+		{
+			name:   "SHOULD parse nostr uri with uppercase scheme and slashes",
+			uri:    " NOSTR://" + testNpub + "\n",
+			prefix: nip19.PrefixNpub,
+		},
+		{
+			name:   "SHOULD parse bare entity",
+			uri:    testNpub,
+			prefix: nip19.PrefixNpub,
+		},
+		{
+			name:  "SHOULD reject private key",
+			uri:   "nostr:" + testNsec,
+			err:   true,
+			errIs: nip21.ErrPrivateKey,
+		},
+		{
+			name: "SHOULD reject other scheme",
+			uri:  "https://example.com",
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix, value, err := nip21.Parse(tt.uri)
+			t.Logf("got %v, %v, %v", prefix, value, err)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if tt.errIs != nil && !errors.Is(err, tt.errIs) {
+				t.Errorf("expected %v, got %v", tt.errIs, err)
+			}
+			if prefix != tt.prefix {
+				t.Errorf("expected %v, got %v", tt.prefix, prefix)
+			}
+		})
+	}
+}
+
+func Test_Format(t *testing.T) {
+	tests := []struct {
+		name   string
+		entity string
+		expect string
+		err    bool
+	}{
+		{
+			name:   "SHOULD format nostr uri",
+			entity: testNpub,
+			expect: "nostr:" + testNpub,
+		},
+		{
+			name:   "SHOULD reject private key",
+			entity: testNsec,
+			err:    true,
+		},
+		{
+			name:   "SHOULD reject invalid entity",
+			entity: "npub1",
+			err:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nip21.Format(tt.entity)
+			t.Logf("got %v, %v", got, err)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if got != tt.expect {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+package nip44
+
+// Internals exposed to the tests of the official vectors.
+var (
+	MessageKeys = messageKeys
+	PaddedLen   = paddedLen
+)
@@ -0,0 +1,222 @@
+// Package nip44 encrypts and decrypts payloads with version 2 of NIP-44:
+// ChaCha20 keyed by an HKDF conversation key, authenticated with HMAC-SHA256
+// and padded to hide the length of the plaintext.
+package nip44
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+
+	"github.com/go-nostr/nostr/keys"
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Version of the payloads produced by this package.
+const Version = 2
+
+// Limits of the plaintext length in bytes.
+const (
+	MinPlaintextSize = 1
+	MaxPlaintextSize = 65535
+)
+
+// Sizes of the parts of a payload in bytes.
+const (
+	nonceSize = 32
+	macSize   = 32
+)
+
+// salt of the HKDF extraction of conversation keys.
+var salt = []byte("nip44-v2")
+
+var (
+	// ErrMAC is returned when the MAC of a payload does not match, either
+	// because it was tampered with or because the key is wrong.
+	ErrMAC = errors.New("invalid mac")
+	// ErrPadding is returned when the padding of a decrypted payload is
+	// malformed.
+	ErrPadding = errors.New("invalid padding")
+	// ErrPayload is returned when a payload is not valid base64 or has an
+	// invalid length.
+	ErrPayload = errors.New("invalid payload")
+	// ErrPlaintext is returned when the plaintext is empty or too long.
+	ErrPlaintext = errors.New("invalid plaintext length")
+	// ErrVersion is returned for payloads of unsupported versions.
+	ErrVersion = errors.New("unsupported version")
+)
+
+// ConversationKey derives the conversation key of the hex encoded private key
+// and the hex encoded x-only public key of the other party. Both parties
+// derive the same key.
+func ConversationKey(prvKeyHex string, pubKeyHex string) ([]byte, error) {
+	prvKey, err := keys.ParsePrivateKey(prvKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	defer prvKey.Zero()
+	pubKey, err := keys.ParsePublicKey(pubKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := prvKey.SharedSecret(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	return conversationKey(shared), nil
+}
+
+// Encrypt encrypts the plaintext from the private key to the public key.
+func Encrypt(prvKeyHex string, pubKeyHex string, plaintext string) (string, error) {
+	key, err := ConversationKey(prvKeyHex, pubKeyHex)
+	if err != nil {
+		return "", err
+	}
+	defer keys.Wipe(key)
+	return EncryptWithKey(key, plaintext, rand.Reader)
+}
+
+// Decrypt decrypts a payload exchanged between the private key and the public
+// key.
+func Decrypt(prvKeyHex string, pubKeyHex string, payload string) (string, error) {
+	key, err := ConversationKey(prvKeyHex, pubKeyHex)
+	if err != nil {
+		return "", err
+	}
+	defer keys.Wipe(key)
+	return DecryptWithKey(key, payload)
+}
+
+// EncryptWithKey encrypts the plaintext with the conversation key and a nonce
+// read from random, and returns the base64 encoded payload.
+func EncryptWithKey(key []byte, plaintext string, random io.Reader) (string, error) {
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(random, nonce); err != nil {
+		return "", fmt.Errorf("unable to generate nonce: %w", err)
+	}
+	padded, err := pad(plaintext)
+	if err != nil {
+		return "", err
+	}
+	chachaKey, chachaNonce, hmacKey, err := messageKeys(key, nonce)
+	if err != nil {
+		return "", err
+	}
+	c, err := chacha20.NewUnauthenticatedCipher(chachaKey, chachaNonce)
+	if err != nil {
+		return "", err
+	}
+	c.XORKeyStream(padded, padded)
+	data := make([]byte, 0, 1+nonceSize+len(padded)+macSize)
+	data = append(data, Version)
+	data = append(data, nonce...)
+	data = append(data, padded...)
+	data = append(data, mac(hmacKey, nonce, padded)...)
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// DecryptWithKey verifies and decrypts the base64 encoded payload with the
+// conversation key.
+func DecryptWithKey(key []byte, payload string) (string, error) {
+	if len(payload) > 0 && payload[0] == '#' {
+		return "", fmt.Errorf("%w: %q", ErrVersion, payload[:1])
+	}
+	if len(payload) < 132 || len(payload) > 87472 {
+		return "", fmt.Errorf("%w: unexpected length %d", ErrPayload, len(payload))
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrPayload, err)
+	}
+	if len(data) < 99 || len(data) > 65603 {
+		return "", fmt.Errorf("%w: unexpected decoded length %d", ErrPayload, len(data))
+	}
+	if data[0] != Version {
+		return "", fmt.Errorf("%w: %d", ErrVersion, data[0])
+	}
+	nonce := data[1 : 1+nonceSize]
+	ciphertext := data[1+nonceSize : len(data)-macSize]
+	chachaKey, chachaNonce, hmacKey, err := messageKeys(key, nonce)
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal(mac(hmacKey, nonce, ciphertext), data[len(data)-macSize:]) {
+		return "", ErrMAC
+	}
+	c, err := chacha20.NewUnauthenticatedCipher(chachaKey, chachaNonce)
+	if err != nil {
+		return "", err
+	}
+	c.XORKeyStream(ciphertext, ciphertext)
+	return unpad(ciphertext)
+}
+
+// conversationKey extracts the conversation key from the ECDH shared secret,
+// which is wiped.
+func conversationKey(shared []byte) []byte {
+	defer keys.Wipe(shared)
+	return hkdf.Extract(sha256.New, shared, salt)
+}
+
+// messageKeys expands the conversation key into the ChaCha20 key and nonce and
+// the HMAC key of a message.
+func messageKeys(key []byte, nonce []byte) (chachaKey, chachaNonce, hmacKey []byte, err error) {
+	if len(key) != sha256.Size {
+		return nil, nil, nil, fmt.Errorf("invalid conversation key: expected %d bytes, got %d", sha256.Size, len(key))
+	}
+	out := make([]byte, chacha20.KeySize+chacha20.NonceSize+sha256.Size)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, key, nonce), out); err != nil {
+		return nil, nil, nil, err
+	}
+	return out[:chacha20.KeySize], out[chacha20.KeySize : chacha20.KeySize+chacha20.NonceSize], out[chacha20.KeySize+chacha20.NonceSize:], nil
+}
+
+// mac computes the HMAC-SHA256 of the nonce and the ciphertext.
+func mac(hmacKey []byte, nonce []byte, ciphertext []byte) []byte {
+	h := hmac.New(sha256.New, hmacKey)
+	h.Write(nonce)
+	h.Write(ciphertext)
+	return h.Sum(nil)
+}
+
+// paddedLen returns the padded length of a plaintext of n bytes.
+func paddedLen(n int) int {
+	if n <= 32 {
+		return 32
+	}
+	next := 1 << bits.Len(uint(n-1))
+	chunk := 32
+	if next > 256 {
+		chunk = next / 8
+	}
+	return chunk * ((n-1)/chunk + 1)
+}
+
+// pad prefixes the plaintext with its big endian uint16 length and appends
+// zeros up to its padded length.
+func pad(plaintext string) ([]byte, error) {
+	n := len(plaintext)
+	if n < MinPlaintextSize || n > MaxPlaintextSize {
+		return nil, fmt.Errorf("%w: %d", ErrPlaintext, n)
+	}
+	padded := make([]byte, 2+paddedLen(n))
+	binary.BigEndian.PutUint16(padded, uint16(n))
+	copy(padded[2:], plaintext)
+	return padded, nil
+}
+
+// unpad returns the plaintext of a padded message.
+func unpad(padded []byte) (string, error) {
+	n := int(binary.BigEndian.Uint16(padded))
+	if n < MinPlaintextSize || len(padded) != 2+paddedLen(n) {
+		return "", ErrPadding
+	}
+	return string(padded[2 : 2+n]), nil
+}
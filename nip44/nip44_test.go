@@ -0,0 +1,249 @@
+package nip44_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/nip44"
+)
+
+const (
+	prvKey1 = "0000000000000000000000000000000000000000000000000000000000000001"
+	pubKey1 = "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	prvKey2 = "0000000000000000000000000000000000000000000000000000000000000002"
+	pubKey2 = "c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"
+	// conversationKey is the conversation key of keys 1 and 2 given by the
+	// NIP-44 test vectors.
+	conversationKey = "c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d"
+	// payload encrypts "a" with the conversation key and the nonce 00…01, as
+	// given by the NIP-44 test vectors.
+	payload = "AgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABee0G5VSK0/9YypIObAtDKfYEAjD35uVkHyB0F4DwrcNaCXlCWZKaArsGrY6M9wnuTMxWfp1RTN9Xga8no+kF5Vsb"
+)
+
+func TestConversationKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		prvKeyHex string
+		pubKeyHex string
+		expect    string
+		err       bool
+	}{
+		{
+			name:      "SHOULD derive conversation key of sender",
+			prvKeyHex: prvKey1,
+			pubKeyHex: pubKey2,
+			expect:    conversationKey,
+		},
+		{
+			name:      "SHOULD derive conversation key of recipient",
+			prvKeyHex: prvKey2,
+			pubKeyHex: pubKey1,
+			expect:    conversationKey,
+		},
+		{
+			name:      "SHOULD reject zero private key",
+			prvKeyHex: "0000000000000000000000000000000000000000000000000000000000000000",
+			pubKeyHex: pubKey2,
+			err:       true,
+		},
+		{
+			name:      "SHOULD reject public key not on curve",
+			prvKeyHex: prvKey1,
+			pubKeyHex: "0000000000000000000000000000000000000000000000000000000000000000",
+			err:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nip44.ConversationKey(tt.prvKeyHex, tt.pubKeyHex)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if err == nil && tt.expect != hex.EncodeToString(got) {
+				t.Errorf("expected %v, got %x", tt.expect, got)
+			}
+			t.Logf("got %x", got)
+		})
+	}
+}
+
+func TestEncryptWithKey(t *testing.T) {
+	key, _ := hex.DecodeString(conversationKey)
+	nonce := make([]byte, 32)
+	nonce[31] = 1
+	got, err := nip44.EncryptWithKey(key, "a", bytes.NewReader(nonce))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload != got {
+		t.Errorf("expected %v, got %v", payload, got)
+	}
+	t.Logf("got %v", got)
+}
+
+func TestEncryptWithKey_Padding(t *testing.T) {
+	key, _ := hex.DecodeString(conversationKey)
+	tests := []struct {
+		name   string
+		size   int
+		expect int
+		err    error
+	}{
+		{name: "SHOULD pad 1 byte to 32", size: 1, expect: 32},
+		{name: "SHOULD pad 32 bytes to 32", size: 32, expect: 32},
+		{name: "SHOULD pad 33 bytes to 64", size: 33, expect: 64},
+		{name: "SHOULD pad 65 bytes to 96", size: 65, expect: 96},
+		{name: "SHOULD pad 200 bytes to 224", size: 200, expect: 224},
+		{name: "SHOULD pad 320 bytes to 320", size: 320, expect: 320},
+		{name: "SHOULD pad 383 bytes to 384", size: 383, expect: 384},
+		{name: "SHOULD pad 515 bytes to 640", size: 515, expect: 640},
+		{name: "SHOULD pad 1020 bytes to 1024", size: 1020, expect: 1024},
+		{name: "SHOULD pad 65535 bytes to 65536", size: 65535, expect: 65536},
+		{name: "SHOULD reject empty plaintext", size: 0, err: nip44.ErrPlaintext},
+		{name: "SHOULD reject plaintext over 65535 bytes", size: 65536, err: nip44.ErrPlaintext},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plaintext := strings.Repeat("x", tt.size)
+			got, err := nip44.EncryptWithKey(key, plaintext, bytes.NewReader(make([]byte, 32)))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			data, _ := base64.StdEncoding.DecodeString(got)
+			// version, nonce, length prefix and mac
+			if n := len(data) - 1 - 32 - 2 - 32; tt.expect != n {
+				t.Errorf("expected %v, got %v", tt.expect, n)
+			}
+			decrypted, err := nip44.DecryptWithKey(key, got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if plaintext != decrypted {
+				t.Errorf("expected %d bytes, got %d", len(plaintext), len(decrypted))
+			}
+		})
+	}
+}
+
+func TestDecryptWithKey(t *testing.T) {
+	key, _ := hex.DecodeString(conversationKey)
+	data, _ := base64.StdEncoding.DecodeString(payload)
+	tamper := func(i int) string {
+		b := append([]byte{}, data...)
+		b[i] ^= 1
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	tests := []struct {
+		name    string
+		payload string
+		expect  string
+		err     error
+	}{
+		{
+			name:    "SHOULD decrypt payload",
+			payload: payload,
+			expect:  "a",
+		},
+		{
+			name:    "SHOULD reject tampered ciphertext",
+			payload: tamper(40),
+			err:     nip44.ErrMAC,
+		},
+		{
+			name:    "SHOULD reject tampered nonce",
+			payload: tamper(1),
+			err:     nip44.ErrMAC,
+		},
+		{
+			name:    "SHOULD reject tampered mac",
+			payload: tamper(len(data) - 1),
+			err:     nip44.ErrMAC,
+		},
+		{
+			name:    "SHOULD reject unknown version",
+			payload: tamper(0),
+			err:     nip44.ErrVersion,
+		},
+		{
+			name:    "SHOULD reject future encoding",
+			payload: "#" + payload[1:],
+			err:     nip44.ErrVersion,
+		},
+		{
+			name:    "SHOULD reject short payload",
+			payload: payload[:100],
+			err:     nip44.ErrPayload,
+		},
+		{
+			name:    "SHOULD reject invalid base64",
+			payload: "!" + payload[1:],
+			err:     nip44.ErrPayload,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nip44.DecryptWithKey(key, tt.payload)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if tt.expect != got {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+			t.Logf("got %v", got)
+		})
+	}
+}
+
+func TestKeySigner(t *testing.T) {
+	ctx := context.Background()
+	sender, err := nip44.NewKeySigner(prvKey1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, err := nip44.NewKeySigner(prvKey2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var _ event.Signer = sender
+	encrypted, err := sender.Encrypt(ctx, pubKey2, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := recipient.Decrypt(ctx, pubKey1, encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "hello" {
+		t.Errorf("expected %v, got %v", "hello", got)
+	}
+	got, err = nip44.Decrypt(prvKey2, pubKey1, encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "hello" {
+		t.Errorf("expected %v, got %v", "hello", got)
+	}
+	evt := event.New(1, got)
+	if err := evt.SignWith(ctx, sender); err != nil {
+		t.Fatal(err)
+	}
+	if evt.PubKey != pubKey1 {
+		t.Errorf("expected %v, got %v", pubKey1, evt.PubKey)
+	}
+	sender.Zero()
+	if _, err := sender.Encrypt(ctx, pubKey2, "hello"); !errors.Is(err, event.ErrZeroed) {
+		t.Errorf("expected %v, got %v", event.ErrZeroed, err)
+	}
+	if err := event.New(1, "").SignWith(ctx, sender); !errors.Is(err, event.ErrZeroed) {
+		t.Errorf("expected %v, got %v", event.ErrZeroed, err)
+	}
+}
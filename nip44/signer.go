@@ -0,0 +1,66 @@
+package nip44
+
+import (
+	"context"
+	"crypto/rand"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/keys"
+)
+
+// Signer is an event.Signer that also encrypts and decrypts NIP-44 payloads
+// with its key, so that remote signers can offer encryption without ever
+// revealing the key.
+type Signer interface {
+	event.Signer
+	// Encrypt encrypts the plaintext to the hex encoded public key.
+	Encrypt(ctx context.Context, pubKeyHex string, plaintext string) (string, error)
+	// Decrypt decrypts a payload exchanged with the hex encoded public key.
+	Decrypt(ctx context.Context, pubKeyHex string, payload string) (string, error)
+}
+
+// NewKeySigner creates a Signer from a hex encoded private key.
+func NewKeySigner(prvKeyHex string) (*KeySigner, error) {
+	signer, err := event.NewKeySigner(prvKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	return &KeySigner{signer}, nil
+}
+
+// KeySigner is an event.KeySigner that also encrypts and decrypts NIP-44
+// payloads with its key. Call Zero to wipe the key once the signer is no
+// longer needed.
+type KeySigner struct {
+	*event.KeySigner
+}
+
+// Encrypt encrypts the plaintext to the hex encoded public key.
+func (s *KeySigner) Encrypt(ctx context.Context, pubKeyHex string, plaintext string) (string, error) {
+	key, err := s.conversationKey(pubKeyHex)
+	if err != nil {
+		return "", err
+	}
+	defer keys.Wipe(key)
+	return EncryptWithKey(key, plaintext, rand.Reader)
+}
+
+// Decrypt decrypts a payload exchanged with the hex encoded public key.
+func (s *KeySigner) Decrypt(ctx context.Context, pubKeyHex string, payload string) (string, error) {
+	key, err := s.conversationKey(pubKeyHex)
+	if err != nil {
+		return "", err
+	}
+	defer keys.Wipe(key)
+	return DecryptWithKey(key, payload)
+}
+
+// conversationKey derives the conversation key of the signer and the hex
+// encoded public key.
+func (s *KeySigner) conversationKey(pubKeyHex string) ([]byte, error) {
+	shared, err := s.SharedSecret(pubKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	return conversationKey(shared), nil
+}
@@ -0,0 +1,240 @@
+package nip44_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-nostr/nostr/keys"
+	"github.com/go-nostr/nostr/nip44"
+)
+
+// vectors are the official NIP-44 test vectors, in the layout of
+// nip44.vectors.json.
+type vectors struct {
+	V2 struct {
+		Valid struct {
+			GetConversationKey []struct {
+				Sec1            string `json:"sec1"`
+				Pub2            string `json:"pub2"`
+				ConversationKey string `json:"conversation_key"`
+			} `json:"get_conversation_key"`
+			GetMessageKeys struct {
+				ConversationKey string `json:"conversation_key"`
+				Keys            []struct {
+					Nonce       string `json:"nonce"`
+					ChachaKey   string `json:"chacha_key"`
+					ChachaNonce string `json:"chacha_nonce"`
+					HmacKey     string `json:"hmac_key"`
+				} `json:"keys"`
+			} `json:"get_message_keys"`
+			CalcPaddedLen  [][2]int `json:"calc_padded_len"`
+			EncryptDecrypt []struct {
+				Sec1            string `json:"sec1"`
+				Sec2            string `json:"sec2"`
+				ConversationKey string `json:"conversation_key"`
+				Nonce           string `json:"nonce"`
+				Plaintext       string `json:"plaintext"`
+				Payload         string `json:"payload"`
+			} `json:"encrypt_decrypt"`
+			EncryptDecryptLongMsg []struct {
+				ConversationKey string `json:"conversation_key"`
+				Nonce           string `json:"nonce"`
+				Pattern         string `json:"pattern"`
+				Repeat          int    `json:"repeat"`
+				PlaintextSHA256 string `json:"plaintext_sha256"`
+				PayloadSHA256   string `json:"payload_sha256"`
+			} `json:"encrypt_decrypt_long_msg"`
+		} `json:"valid"`
+		Invalid struct {
+			EncryptMsgLengths  []int `json:"encrypt_msg_lengths"`
+			GetConversationKey []struct {
+				Sec1 string `json:"sec1"`
+				Pub2 string `json:"pub2"`
+				Note string `json:"note"`
+			} `json:"get_conversation_key"`
+			Decrypt []struct {
+				ConversationKey string `json:"conversation_key"`
+				Nonce           string `json:"nonce"`
+				Plaintext       string `json:"plaintext"`
+				Payload         string `json:"payload"`
+				Note            string `json:"note"`
+			} `json:"decrypt"`
+		} `json:"invalid"`
+	} `json:"v2"`
+}
+
+// loadVectors reads the official test vectors from testdata.
+func loadVectors(t *testing.T) *vectors {
+	t.Helper()
+	data, err := os.ReadFile("testdata/nip44.vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v vectors
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	return &v
+}
+
+// mustHex decodes the hex encoded string.
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestVectors_GetConversationKey(t *testing.T) {
+	for _, tt := range loadVectors(t).V2.Valid.GetConversationKey {
+		t.Run("SHOULD derive "+tt.ConversationKey, func(t *testing.T) {
+			got, err := nip44.ConversationKey(tt.Sec1, tt.Pub2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.ConversationKey != hex.EncodeToString(got) {
+				t.Errorf("expected %v, got %x", tt.ConversationKey, got)
+			}
+		})
+	}
+}
+
+func TestVectors_GetMessageKeys(t *testing.T) {
+	v := loadVectors(t).V2.Valid.GetMessageKeys
+	key := mustHex(t, v.ConversationKey)
+	for _, tt := range v.Keys {
+		t.Run("SHOULD expand nonce "+tt.Nonce, func(t *testing.T) {
+			chachaKey, chachaNonce, hmacKey, err := nip44.MessageKeys(key, mustHex(t, tt.Nonce))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range []struct {
+				expect string
+				got    []byte
+			}{{tt.ChachaKey, chachaKey}, {tt.ChachaNonce, chachaNonce}, {tt.HmacKey, hmacKey}} {
+				if c.expect != hex.EncodeToString(c.got) {
+					t.Errorf("expected %v, got %x", c.expect, c.got)
+				}
+			}
+		})
+	}
+}
+
+func TestVectors_CalcPaddedLen(t *testing.T) {
+	for _, tt := range loadVectors(t).V2.Valid.CalcPaddedLen {
+		if got := nip44.PaddedLen(tt[0]); tt[1] != got {
+			t.Errorf("SHOULD pad %d bytes: expected %v, got %v", tt[0], tt[1], got)
+		}
+	}
+}
+
+func TestVectors_EncryptDecrypt(t *testing.T) {
+	for _, tt := range loadVectors(t).V2.Valid.EncryptDecrypt {
+		t.Run("SHOULD encrypt "+tt.Plaintext, func(t *testing.T) {
+			sec2, err := keys.ParsePrivateKey(tt.Sec2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			key, err := nip44.ConversationKey(tt.Sec1, sec2.PublicKey().Hex())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.ConversationKey != hex.EncodeToString(key) {
+				t.Errorf("expected %v, got %x", tt.ConversationKey, key)
+			}
+			got, err := nip44.EncryptWithKey(key, tt.Plaintext, bytes.NewReader(mustHex(t, tt.Nonce)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.Payload != got {
+				t.Errorf("expected %v, got %v", tt.Payload, got)
+			}
+			decrypted, err := nip44.DecryptWithKey(key, tt.Payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.Plaintext != decrypted {
+				t.Errorf("expected %v, got %v", tt.Plaintext, decrypted)
+			}
+		})
+	}
+}
+
+func TestVectors_EncryptDecryptLongMsg(t *testing.T) {
+	for _, tt := range loadVectors(t).V2.Valid.EncryptDecryptLongMsg {
+		t.Run("SHOULD encrypt "+tt.Pattern, func(t *testing.T) {
+			plaintext := strings.Repeat(tt.Pattern, tt.Repeat)
+			if got := sha256.Sum256([]byte(plaintext)); tt.PlaintextSHA256 != hex.EncodeToString(got[:]) {
+				t.Fatalf("expected plaintext hash %v, got %x", tt.PlaintextSHA256, got)
+			}
+			key := mustHex(t, tt.ConversationKey)
+			payload, err := nip44.EncryptWithKey(key, plaintext, bytes.NewReader(mustHex(t, tt.Nonce)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sha256.Sum256([]byte(payload)); tt.PayloadSHA256 != hex.EncodeToString(got[:]) {
+				t.Errorf("expected payload hash %v, got %x", tt.PayloadSHA256, got)
+			}
+			decrypted, err := nip44.DecryptWithKey(key, payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if plaintext != decrypted {
+				t.Errorf("expected %d bytes, got %d", len(plaintext), len(decrypted))
+			}
+		})
+	}
+}
+
+func TestVectors_InvalidEncryptMsgLengths(t *testing.T) {
+	key := make([]byte, 32)
+	for _, n := range loadVectors(t).V2.Invalid.EncryptMsgLengths {
+		_, err := nip44.EncryptWithKey(key, strings.Repeat("a", n), bytes.NewReader(make([]byte, 32)))
+		if !errors.Is(err, nip44.ErrPlaintext) {
+			t.Errorf("SHOULD reject %d bytes: expected %v, got %v", n, nip44.ErrPlaintext, err)
+		}
+	}
+}
+
+func TestVectors_InvalidGetConversationKey(t *testing.T) {
+	for _, tt := range loadVectors(t).V2.Invalid.GetConversationKey {
+		t.Run("SHOULD reject "+tt.Note, func(t *testing.T) {
+			got, err := nip44.ConversationKey(tt.Sec1, tt.Pub2)
+			if !errors.Is(err, keys.ErrInvalidKey) {
+				t.Errorf("expected %v, got %x, %v", keys.ErrInvalidKey, got, err)
+			}
+		})
+	}
+}
+
+func TestVectors_InvalidDecrypt(t *testing.T) {
+	errs := map[string]error{
+		"unknown version":        nip44.ErrVersion,
+		"invalid base64":         nip44.ErrPayload,
+		"invalid hmac":           nip44.ErrMAC,
+		"invalid padding":        nip44.ErrPadding,
+		"invalid payload length": nip44.ErrPayload,
+	}
+	for _, tt := range loadVectors(t).V2.Invalid.Decrypt {
+		t.Run("SHOULD reject "+tt.Note, func(t *testing.T) {
+			var expect error
+			for prefix, err := range errs {
+				if strings.HasPrefix(tt.Note, prefix) {
+					expect = err
+				}
+			}
+			got, err := nip44.DecryptWithKey(mustHex(t, tt.ConversationKey), tt.Payload)
+			if err == nil || !errors.Is(err, expect) {
+				t.Errorf("expected %v, got %q, %v", expect, got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+// Package nprofile encodes and decodes NIP-19 "nprofile" entities, which
+// point to a profile with relays where it may be found.
+package nprofile
+
+import (
+	"fmt"
+
+	"github.com/go-nostr/nostr/internal/tlv"
+)
+
+// Prefix is the human-readable part of nprofile entities.
+const Prefix = "nprofile"
+
+// Profile is a profile pointer.
+type Profile struct {
+	PubKey string   // PubKey is the hex encoded public key of the profile.
+	Relays []string // Relays are URLs of relays where the profile may be found.
+}
+
+// Decode decodes the nprofile entity.
+func Decode(nprofile string) (*Profile, error) {
+	entries, err := tlv.Decode(Prefix, nprofile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nprofile: %w", err)
+	}
+	p := &Profile{}
+	for _, e := range entries {
+		switch e.Type {
+		case tlv.TypeSpecial:
+			if p.PubKey, err = tlv.ParseHex32(e); err != nil {
+				return nil, fmt.Errorf("invalid nprofile: %w", err)
+			}
+		case tlv.TypeRelay:
+			p.Relays = append(p.Relays, string(e.Value))
+		}
+	}
+	if p.PubKey == "" {
+		return nil, fmt.Errorf("invalid nprofile: missing public key")
+	}
+	return p, nil
+}
+
+// Encode encodes the profile as an nprofile entity.
+func Encode(p *Profile) (string, error) {
+	pubKey, err := tlv.Hex32(tlv.TypeSpecial, p.PubKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %w", err)
+	}
+	return tlv.Encode(Prefix, append([]tlv.Entry{pubKey}, tlv.Relays(p.Relays)...))
+}
@@ -0,0 +1,100 @@
+package nprofile_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/go-nostr/nostr/internal/tlv"
+	"github.com/go-nostr/nostr/nprofile"
+)
+
+// spec is the nprofile example of NIP-19.
+var spec = struct {
+	nprofile string
+	profile  *nprofile.Profile
+}{
+	nprofile: "nprofile1qqsrhuxx8l9ex335q7he0f09aej04zpazpl0ne2cgukyawd24mayt8gpp4mhxue69uhhytnc9e3k7mgpz4mhxue69uhkg6nzv9ejuumpv34kytnrdaksjlyr9p",
+	profile: &nprofile.Profile{
+		PubKey: "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d",
+		Relays: []string{"wss://r.x.com", "wss://djbas.sadkb.com"},
+	},
+}
+
+func Test_Decode(t *testing.T) {
+	truncated, err := bech32.ConvertBits([]byte{0, 32, 0x3b, 0xf0}, 8, 5, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	truncatedStr, err := bech32.Encode(nprofile.Prefix, truncated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		s      string
+		expect *nprofile.Profile
+		err    error
+	}{
+		{
+			name:   "SHOULD decode spec example",
+			s:      spec.nprofile,
+			expect: spec.profile,
+		},
+		{
+			name: "SHOULD reject other prefix",
+			s:    "npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjptg",
+			err:  tlv.ErrPrefix,
+		},
+		{
+			name: "SHOULD reject truncated tlv",
+			s:    truncatedStr,
+			err:  tlv.ErrTruncated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nprofile.Decode(tt.s)
+			t.Logf("got %+v, %v", got, err)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+			if !reflect.DeepEqual(tt.expect, got) {
+				t.Errorf("expected %+v, got %+v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func Test_Encode(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile *nprofile.Profile
+		expect  string
+		err     bool
+	}{
+		{
+			name:    "SHOULD encode spec example",
+			profile: spec.profile,
+			expect:  spec.nprofile,
+		},
+		{
+			name:    "SHOULD reject invalid public key",
+			profile: &nprofile.Profile{PubKey: "abc"},
+			err:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nprofile.Encode(tt.profile)
+			t.Logf("got %v, %v", got, err)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if got != tt.expect {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
@@ -14,6 +14,7 @@ func Test_Decode(t *testing.T) {
 		name   string
 		args   args
 		expect string
+		err    bool
 	}{
 		{
 			name: "SHOULD decode npub",
@@ -22,12 +23,19 @@ func Test_Decode(t *testing.T) {
 			},
 			expect: "7e7e9c42a91bfef19fa929e5fda1b72e0ebc1a4c1141673e2794234d86addf4e",
 		},
+		{
+			name: "SHOULD reject nsec",
+			args: args{
+				npub: "nsec1vl029mgpspedva04g90vltkh6fvh240zqtv9k0t9af8935ke9laqsnlfe5",
+			},
+			err: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got, err := npub.Decode(tt.args.npub)
-			if err != nil {
-				t.Errorf("%v", err.Error())
+			if (err != nil) != tt.err {
+				t.Errorf("expected error %v, got %v", tt.err, err)
 			}
 			if tt.expect != got {
 				t.Errorf("expected %v, got %v", tt.expect, got)
@@ -1,9 +1,34 @@
+// Package nrelay encodes and decodes NIP-19 "nrelay" entities, which point to
+// a relay.
 package nrelay
 
-func Decode() {
+import (
+	"fmt"
 
-}
+	"github.com/go-nostr/nostr/internal/tlv"
+)
+
+// Prefix is the human-readable part of nrelay entities.
+const Prefix = "nrelay"
 
-func Encode() {
+// Decode decodes the nrelay entity into the URL of the relay.
+func Decode(nrelay string) (string, error) {
+	entries, err := tlv.Decode(Prefix, nrelay)
+	if err != nil {
+		return "", fmt.Errorf("invalid nrelay: %w", err)
+	}
+	for _, e := range entries {
+		if e.Type == tlv.TypeSpecial {
+			return string(e.Value), nil
+		}
+	}
+	return "", fmt.Errorf("invalid nrelay: missing relay url")
+}
 
+// Encode encodes the URL of the relay as an nrelay entity.
+func Encode(url string) (string, error) {
+	if url == "" {
+		return "", fmt.Errorf("invalid relay url")
+	}
+	return tlv.Encode(Prefix, []tlv.Entry{{Type: tlv.TypeSpecial, Value: []byte(url)}})
 }
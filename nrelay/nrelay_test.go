@@ -0,0 +1,78 @@
+package nrelay_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-nostr/nostr/internal/tlv"
+	"github.com/go-nostr/nostr/nrelay"
+)
+
+func Test_EncodeDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{
+			name: "SHOULD round-trip relay url",
+			url:  "wss://relay.nostr.example",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := nrelay.Encode(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := nrelay.Decode(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.url {
+				t.Errorf("expected %v, got %v", tt.url, got)
+			}
+		})
+	}
+}
+
+// Test_Vectors checks nrelay entities produced by an independent
+// implementation of the BIP-173 reference encoder.
+func Test_Vectors(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		url  string
+	}{
+		{
+			name: "SHOULD match relay url vector",
+			s:    "nrelay1qqvhwumn8ghj7un9d3shjtnwdaehgu3wv4uxzmtsd3jsh6r089",
+			url:  "wss://relay.nostr.example",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nrelay.Decode(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.url {
+				t.Errorf("expected %v, got %v", tt.url, got)
+			}
+			s, err := nrelay.Encode(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s != tt.s {
+				t.Errorf("expected %v, got %v", tt.s, s)
+			}
+		})
+	}
+}
+
+func Test_Decode(t *testing.T) {
+	_, err := nrelay.Decode("npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjptg")
+	t.Logf("got %v", err)
+	if !errors.Is(err, tlv.ErrPrefix) {
+		t.Errorf("expected %v, got %v", tlv.ErrPrefix, err)
+	}
+}
@@ -1,55 +1,34 @@
 package nsec
 
 import (
-	"encoding/hex"
-	"fmt"
-	"os"
-
-	"github.com/btcsuite/btcd/btcec/v2"
-	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/go-nostr/nostr/keys"
 )
 
-// Decode bech32 public key with 'nsec' human-readable part into hex encoded private key
+// Decode bech32 private key with 'nsec' human-readable part into hex encoded private key
 func Decode(nsec string) (string, error) {
-	hrp, byt, err := bech32.DecodeNoLimit(nsec)
+	prvKey, err := keys.DecodeNsec(nsec)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-	if hrp != "nsec" {
-		return "", fmt.Errorf("invalid nsec")
-	}
-	grp, err := bech32.ConvertBits(byt, 5, 8, false)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return "", err
 	}
-	return hex.EncodeToString(grp[0:32]), nil
+	defer prvKey.Zero()
+	return prvKey.Hex(), nil
 }
 
 // Encode hex private key as bech32 with "nsec" human readable part
 func Encode(prvKeyHex string) (string, error) {
-	data, err := hex.DecodeString(prvKeyHex)
-	if err != nil {
-		return "", fmt.Errorf("invalid hex encoded private key")
-	}
-	grp, err := bech32.ConvertBits(data, 8, 5, true)
+	prvKey, err := keys.ParsePrivateKey(prvKeyHex)
 	if err != nil {
 		return "", err
 	}
-	return bech32.Encode("nsec", grp)
+	defer prvKey.Zero()
+	return prvKey.Nsec(), nil
 }
 
 func New() (prvKeyHex string, pubKeyHex string, nsec string, err error) {
-	prvKey, err := btcec.NewPrivateKey()
-	if err != nil {
-		return "", "", "", err
-	}
-	prvKeyHex = hex.EncodeToString(prvKey.Serialize())
-	pubKeyHex = hex.EncodeToString(prvKey.PubKey().SerializeCompressed()[1:])
-	nsec, err = Encode(prvKeyHex)
+	prvKey, err := keys.GeneratePrivateKey()
 	if err != nil {
 		return "", "", "", err
 	}
-	return prvKeyHex, pubKeyHex, nsec, nil
+	defer prvKey.Zero()
+	return prvKey.Hex(), prvKey.PublicKey().Hex(), prvKey.Nsec(), nil
 }
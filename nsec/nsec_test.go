@@ -14,6 +14,7 @@ func Test_Decode(t *testing.T) {
 		name   string
 		args   args
 		expect string
+		err    bool
 	}{
 		{
 			name: "SHOULD decode nsec",
@@ -22,12 +23,26 @@ func Test_Decode(t *testing.T) {
 			},
 			expect: "67dea2ed018072d675f5415ecfaed7d2597555e202d85b3d65ea4e58d2d92ffa",
 		},
+		{
+			name: "SHOULD reject bad checksum",
+			args: args{
+				nsec: "nsec1vl029mgpspedva04g90vltkh6fvh240zqtv9k0t9af8935ke9laqsnlfe6",
+			},
+			err: true,
+		},
+		{
+			name: "SHOULD reject npub",
+			args: args{
+				nsec: "npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjptg",
+			},
+			err: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got, err := nsec.Decode(tt.args.nsec)
-			if err != nil {
-				t.Errorf("%v", err.Error())
+			if (err != nil) != tt.err {
+				t.Errorf("expected error %v, got %v", tt.err, err)
 			}
 			if tt.expect != got {
 				t.Errorf("expected %v, got %v", tt.expect, got)
@@ -0,0 +1,171 @@
+package relay
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/message"
+	"nhooyr.io/websocket"
+)
+
+// ErrSlowConnection is reported when a client is disconnected because it does
+// not read the events broadcast to it fast enough.
+var ErrSlowConnection = errors.New("connection is too slow")
+
+// sendBufferSize is the number of events broadcast to a connection that are
+// buffered until they are written to the client.
+const sendBufferSize = 100
+
+// Conn is the websocket connection of a client. It holds the subscriptions of
+// the client, the public key it authenticated with, values stored by handlers
+// to keep per-connection state, and the buffer of events broadcast to it.
+type Conn struct {
+	challenge  string
+	challenged bool
+	out        chan message.Message
+	pubKey     string
+	relayURL   string
+	remoteAddr string
+	subs       map[string]message.Filters
+	values     map[any]any
+	ws         *websocket.Conn
+	mu         sync.Mutex
+}
+
+// newConn wraps the websocket connection of the client at remoteAddr, which
+// connected to the relay at relayURL.
+func newConn(ws *websocket.Conn, relayURL string, remoteAddr string) (*Conn, error) {
+	challenge := make([]byte, 16)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, fmt.Errorf("could not generate challenge: %w", err)
+	}
+	return &Conn{
+		challenge:  hex.EncodeToString(challenge),
+		out:        make(chan message.Message, sendBufferSize),
+		relayURL:   relayURL,
+		remoteAddr: remoteAddr,
+		subs:       make(map[string]message.Filters),
+		values:     make(map[any]any),
+		ws:         ws,
+	}, nil
+}
+
+// Challenge returns the NIP-42 challenge the client must sign to authenticate.
+func (c *Conn) Challenge() string {
+	return c.challenge
+}
+
+// Close closes the connection with the given reason.
+func (c *Conn) Close(reason string) error {
+	return c.ws.Close(websocket.StatusNormalClosure, reason)
+}
+
+// PubKey returns the public key the client authenticated with, or an empty
+// string if it has not authenticated.
+func (c *Conn) PubKey() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pubKey
+}
+
+// RemoteAddr returns the network address of the client.
+func (c *Conn) RemoteAddr() string {
+	return c.remoteAddr
+}
+
+// Send sends the message to the client.
+func (c *Conn) Send(ctx context.Context, msg message.Message) error {
+	data, err := msg.Marshal()
+	if err != nil {
+		return err
+	}
+	return c.ws.Write(ctx, websocket.MessageText, data)
+}
+
+// SetValue stores a value under the key for the lifetime of the connection.
+func (c *Conn) SetValue(key any, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+}
+
+// Subscriptions returns the filters of the open subscriptions, keyed by
+// subscription ID.
+func (c *Conn) Subscriptions() map[string]message.Filters {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	subs := make(map[string]message.Filters, len(c.subs))
+	for id, filters := range c.subs {
+		subs[id] = filters
+	}
+	return subs
+}
+
+// Value returns the value stored under the key, or nil if there is none.
+func (c *Conn) Value(key any) any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key]
+}
+
+// authenticate marks the client as authenticated with the public key.
+func (c *Conn) authenticate(pubKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pubKey = pubKey
+}
+
+// enqueue buffers the message to be written to the client, and reports
+// whether the buffer had room for it.
+func (c *Conn) enqueue(msg message.Message) bool {
+	select {
+	case c.out <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+// markChallenged records that the challenge was sent and reports whether it
+// was not sent before.
+func (c *Conn) markChallenged() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.challenged {
+		return false
+	}
+	c.challenged = true
+	return true
+}
+
+// matches returns the IDs of the subscriptions matching the event.
+func (c *Conn) matches(evt *event.Event) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var ids []string
+	for id, filters := range c.subs {
+		if filters.Match(evt) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// subscribe adds the subscription, replacing any subscription with the same ID.
+func (c *Conn) subscribe(id string, filters message.Filters) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subs[id] = filters
+}
+
+// unsubscribe removes the subscription.
+func (c *Conn) unsubscribe(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.subs, id)
+}
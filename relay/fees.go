@@ -2,5 +2,20 @@ package relay
 
 // Fees is a struct that contains information about the fees associated with an operation.
 type Fees struct {
-	Admission *Admission `json:"admission,omitempty"` // Admission represents the admission fee for an operation, if applicable.
+	Admission    []*Fee `json:"admission,omitempty"`    // Admission lists the fees to be admitted to the relay.
+	Subscription []*Fee `json:"subscription,omitempty"` // Subscription lists the fees of recurring subscriptions.
+	Publication  []*Fee `json:"publication,omitempty"`  // Publication lists the fees to publish events of given kinds.
 }
+
+// Fee represents a single fee of a relay.
+type Fee struct {
+	Kinds  []int  `json:"kinds,omitempty"`  // Kinds specifies the kinds of events the fee applies to.
+	Amount int    `json:"amount"`           // Amount indicates the amount to pay.
+	Unit   string `json:"unit"`             // Unit denotes the currency unit of the amount, such as "msats".
+	Period int    `json:"period,omitempty"` // Period is the duration of a subscription in seconds.
+}
+
+// Admission represents the admission requirements for accessing a Nostr relay.
+//
+// Deprecated: use Fee.
+type Admission = Fee
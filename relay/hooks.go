@@ -0,0 +1,63 @@
+package relay
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/message"
+	"github.com/go-nostr/nostr/message/okmessage"
+)
+
+// RejectEventFunc decides whether an event sent by a client is rejected. It
+// returns an empty string to accept the event, or the reason of the rejection
+// preferably starting with a machine-readable prefix such as "blocked: ".
+type RejectEventFunc func(ctx context.Context, c *Conn, evt *event.Event) string
+
+// RejectFilterFunc decides whether a filter of a subscription or count request
+// is rejected. It returns an empty string to accept the filter, or the reason
+// of the rejection.
+type RejectFilterFunc func(ctx context.Context, c *Conn, f *message.Filter) string
+
+// EventSavedFunc is called after an event sent by a client has been stored.
+type EventSavedFunc func(ctx context.Context, c *Conn, evt *event.Event)
+
+// rejectEvent runs the RejectEvent hooks in order and returns the reason of the
+// first one rejecting the event.
+func (rl *Relay) rejectEvent(ctx context.Context, c *Conn, evt *event.Event) string {
+	for _, fn := range rl.RejectEvent {
+		if reason := fn(ctx, c, evt); reason != "" {
+			return prefixed(reason)
+		}
+	}
+	return ""
+}
+
+// rejectFilters runs the RejectFilter hooks in order on every filter and
+// returns the reason of the first one rejecting a filter.
+func (rl *Relay) rejectFilters(ctx context.Context, c *Conn, filters message.Filters) string {
+	for _, f := range filters {
+		for _, fn := range rl.RejectFilter {
+			if reason := fn(ctx, c, f); reason != "" {
+				return prefixed(reason)
+			}
+		}
+	}
+	return ""
+}
+
+// eventSaved runs the OnEventSaved hooks in order.
+func (rl *Relay) eventSaved(ctx context.Context, c *Conn, evt *event.Event) {
+	for _, fn := range rl.OnEventSaved {
+		fn(ctx, c, evt)
+	}
+}
+
+// prefixed returns the reason with the "blocked:" prefix added when it has no
+// machine-readable prefix.
+func prefixed(reason string) string {
+	if prefix, _ := okmessage.ParseReason(reason); prefix != "" {
+		return reason
+	}
+	return okmessage.Reason(okmessage.PrefixBlocked, strings.TrimSpace(reason))
+}
@@ -6,9 +6,9 @@ import "github.com/go-nostr/nostr/tag"
 type InformationDocument struct {
 	Name           string       `json:"name,omitempty"`
 	Description    string       `json:"description,omitempty"`
-	PubKey         string       `json:"pub_key,omitempty"`
+	PubKey         string       `json:"pubkey,omitempty"`
 	Contact        string       `json:"contact,omitempty"`
-	SupportedNIPs  string       `json:"supported_nips,omitempty"`
+	SupportedNIPs  []int        `json:"supported_nips,omitempty"`
 	Software       string       `json:"software,omitempty"`
 	Version        string       `json:"version,omitempty"`
 	Limitations    *Limitations `json:"limitations,omitempty"`
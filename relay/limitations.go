@@ -1,5 +1,14 @@
 package relay
 
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/message"
+	"github.com/go-nostr/nostr/message/okmessage"
+)
+
 // Limitations represents various limits and constraints imposed by a Nostr server.
 type Limitations struct {
 	AuthRequired     bool `json:"auth_required,omitempty"`      // Indicates if authentication is required.
@@ -14,3 +23,66 @@ type Limitations struct {
 	MinPrefix        int  `json:"min_prefix,omitempty"`         // Minimum prefix length.
 	PaymentRequired  bool `json:"payment_required,omitempty"`   // Indicates if payment is required.
 }
+
+// checkEvent returns the reason an event sent by the connection violates the
+// limitations, or an empty string if it does not.
+func (l *Limitations) checkEvent(c *Conn, evt *event.Event) string {
+	if l == nil {
+		return ""
+	}
+	if l.AuthRequired && c.PubKey() == "" {
+		return okmessage.Reason(okmessage.PrefixAuthRequired, "authentication is required to publish events")
+	}
+	if l.MaxEventTags > 0 && len(evt.Tags) > l.MaxEventTags {
+		return okmessage.Reason(okmessage.PrefixInvalid, fmt.Sprintf("event has more than %d tags", l.MaxEventTags))
+	}
+	if l.MaxContentLength > 0 && utf8.RuneCountInString(evt.Content) > l.MaxContentLength {
+		return okmessage.Reason(okmessage.PrefixInvalid, fmt.Sprintf("content is longer than %d characters", l.MaxContentLength))
+	}
+	return ""
+}
+
+// checkFilters returns the reason a subscription or count request sent by the
+// connection violates the limitations, or an empty string if it does not. The
+// limit of each filter is clamped to the maximum limit. Set subscribe for
+// requests opening a subscription, so the number of subscriptions is checked.
+func (l *Limitations) checkFilters(c *Conn, subscriptionID string, filters message.Filters, subscribe bool) string {
+	if l == nil {
+		return ""
+	}
+	if l.AuthRequired && c.PubKey() == "" {
+		return okmessage.Reason(okmessage.PrefixAuthRequired, "authentication is required to subscribe")
+	}
+	if l.MaxSubIDLength > 0 && len(subscriptionID) > l.MaxSubIDLength {
+		return okmessage.Reason(okmessage.PrefixInvalid, fmt.Sprintf("subscription id is longer than %d characters", l.MaxSubIDLength))
+	}
+	if l.MaxFilters > 0 && len(filters) > l.MaxFilters {
+		return okmessage.Reason(okmessage.PrefixInvalid, fmt.Sprintf("request has more than %d filters", l.MaxFilters))
+	}
+	if subscribe && l.MaxSubscriptions > 0 {
+		subs := c.Subscriptions()
+		if _, ok := subs[subscriptionID]; !ok && len(subs) >= l.MaxSubscriptions {
+			return okmessage.Reason(okmessage.PrefixBlocked, fmt.Sprintf("more than %d subscriptions", l.MaxSubscriptions))
+		}
+	}
+	for _, f := range filters {
+		if l.MinPrefix > 0 {
+			if err := f.CheckPrefixes(l.MinPrefix); err != nil {
+				return okmessage.Reason(okmessage.PrefixInvalid, err.Error())
+			}
+		}
+		if l.MaxLimit > 0 && (f.Limit == 0 || f.Limit > l.MaxLimit) {
+			f.Limit = l.MaxLimit
+		}
+	}
+	return ""
+}
+
+// checkMessageLength returns the reason a message of n bytes violates the
+// limitations, or an empty string if it does not.
+func (l *Limitations) checkMessageLength(n int) string {
+	if l == nil || l.MaxMessageLength <= 0 || n <= l.MaxMessageLength {
+		return ""
+	}
+	return okmessage.Reason(okmessage.PrefixInvalid, fmt.Sprintf("message is longer than %d bytes", l.MaxMessageLength))
+}
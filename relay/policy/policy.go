@@ -0,0 +1,86 @@
+// Package policy provides reusable hooks for the RejectEvent policy chain of
+// a relay.Relay.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/message/okmessage"
+	"github.com/go-nostr/nostr/relay"
+)
+
+// AllowPubKeys rejects events from authors not in the list.
+func AllowPubKeys(pubKeys ...string) relay.RejectEventFunc {
+	allowed := toSet(pubKeys)
+	return func(ctx context.Context, c *relay.Conn, evt *event.Event) string {
+		if _, ok := allowed[evt.PubKey]; !ok {
+			return okmessage.Reason(okmessage.PrefixBlocked, "pubkey is not allowed")
+		}
+		return ""
+	}
+}
+
+// DenyPubKeys rejects events from authors in the list.
+func DenyPubKeys(pubKeys ...string) relay.RejectEventFunc {
+	denied := toSet(pubKeys)
+	return func(ctx context.Context, c *relay.Conn, evt *event.Event) string {
+		if _, ok := denied[evt.PubKey]; ok {
+			return okmessage.Reason(okmessage.PrefixBlocked, "pubkey is denied")
+		}
+		return ""
+	}
+}
+
+// AllowKinds rejects events of kinds not in the list.
+func AllowKinds(kinds ...int) relay.RejectEventFunc {
+	allowed := make(map[int]struct{}, len(kinds))
+	for _, k := range kinds {
+		allowed[k] = struct{}{}
+	}
+	return func(ctx context.Context, c *relay.Conn, evt *event.Event) string {
+		if _, ok := allowed[evt.Kind]; !ok {
+			return okmessage.Reason(okmessage.PrefixBlocked, fmt.Sprintf("kind %d is not allowed", evt.Kind))
+		}
+		return ""
+	}
+}
+
+// MaxContentLength rejects events with a content longer than max characters,
+// counted as runes like relay.Limitations.MaxContentLength.
+func MaxContentLength(max int) relay.RejectEventFunc {
+	return func(ctx context.Context, c *relay.Conn, evt *event.Event) string {
+		if utf8.RuneCountInString(evt.Content) > max {
+			return okmessage.Reason(okmessage.PrefixInvalid, fmt.Sprintf("content is longer than %d characters", max))
+		}
+		return ""
+	}
+}
+
+// CreatedAtWindow rejects events created more than past before or more than
+// future after the current time. A zero duration disables the bound.
+func CreatedAtWindow(past time.Duration, future time.Duration) relay.RejectEventFunc {
+	return func(ctx context.Context, c *relay.Conn, evt *event.Event) string {
+		now := time.Now()
+		createdAt := time.Unix(int64(evt.CreatedAt), 0)
+		if past > 0 && createdAt.Before(now.Add(-past)) {
+			return okmessage.Reason(okmessage.PrefixInvalid, "created_at is too far in the past")
+		}
+		if future > 0 && createdAt.After(now.Add(future)) {
+			return okmessage.Reason(okmessage.PrefixInvalid, "created_at is too far in the future")
+		}
+		return ""
+	}
+}
+
+// toSet returns the values as a set.
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,112 @@
+package policy_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/relay"
+	"github.com/go-nostr/nostr/relay/policy"
+)
+
+func TestPolicies(t *testing.T) {
+	alice := strings.Repeat("a", 64)
+	bob := strings.Repeat("b", 64)
+	now := int(time.Now().Unix())
+	tests := []struct {
+		name   string
+		policy relay.RejectEventFunc
+		evt    *event.Event
+		expect string
+	}{
+		{
+			name:   "SHOULD accept allowed pubkey",
+			policy: policy.AllowPubKeys(alice),
+			evt:    &event.Event{PubKey: alice},
+			expect: "",
+		},
+		{
+			name:   "SHOULD reject pubkey not allowed",
+			policy: policy.AllowPubKeys(alice),
+			evt:    &event.Event{PubKey: bob},
+			expect: "blocked: pubkey is not allowed",
+		},
+		{
+			name:   "SHOULD reject denied pubkey",
+			policy: policy.DenyPubKeys(bob),
+			evt:    &event.Event{PubKey: bob},
+			expect: "blocked: pubkey is denied",
+		},
+		{
+			name:   "SHOULD accept pubkey not denied",
+			policy: policy.DenyPubKeys(bob),
+			evt:    &event.Event{PubKey: alice},
+			expect: "",
+		},
+		{
+			name:   "SHOULD accept allowed kind",
+			policy: policy.AllowKinds(0, 1),
+			evt:    &event.Event{Kind: 1},
+			expect: "",
+		},
+		{
+			name:   "SHOULD reject kind not allowed",
+			policy: policy.AllowKinds(0, 1),
+			evt:    &event.Event{Kind: 4},
+			expect: "blocked: kind 4 is not allowed",
+		},
+		{
+			name:   "SHOULD accept content within length cap",
+			policy: policy.MaxContentLength(5),
+			evt:    &event.Event{Content: "hello"},
+			expect: "",
+		},
+		{
+			name:   "SHOULD reject content above length cap",
+			policy: policy.MaxContentLength(5),
+			evt:    &event.Event{Content: "hello!"},
+			expect: "invalid: content is longer than 5 characters",
+		},
+		{
+			name:   "SHOULD count multibyte characters once",
+			policy: policy.MaxContentLength(5),
+			evt:    &event.Event{Content: "héllö"},
+			expect: "",
+		},
+		{
+			name:   "SHOULD accept event created within window",
+			policy: policy.CreatedAtWindow(time.Hour, time.Minute),
+			evt:    &event.Event{CreatedAt: now - 60},
+			expect: "",
+		},
+		{
+			name:   "SHOULD reject event created too long ago",
+			policy: policy.CreatedAtWindow(time.Hour, time.Minute),
+			evt:    &event.Event{CreatedAt: now - 7200},
+			expect: "invalid: created_at is too far in the past",
+		},
+		{
+			name:   "SHOULD reject event created in the future",
+			policy: policy.CreatedAtWindow(time.Hour, time.Minute),
+			evt:    &event.Event{CreatedAt: now + 3600},
+			expect: "invalid: created_at is too far in the future",
+		},
+		{
+			name:   "SHOULD ignore disabled bound",
+			policy: policy.CreatedAtWindow(0, time.Minute),
+			evt:    &event.Event{CreatedAt: 0},
+			expect: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.policy(context.TODO(), nil, tt.evt)
+			if got != tt.expect {
+				t.Errorf("expected %q, got %q", tt.expect, got)
+			}
+			t.Logf("got %v", got)
+		})
+	}
+}
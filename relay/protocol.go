@@ -0,0 +1,245 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/event/clientauthenticationevent"
+	"github.com/go-nostr/nostr/message"
+	"github.com/go-nostr/nostr/message/authmessage"
+	"github.com/go-nostr/nostr/message/closedmessage"
+	"github.com/go-nostr/nostr/message/eosemessage"
+	"github.com/go-nostr/nostr/message/eventmessage"
+	"github.com/go-nostr/nostr/message/noticemessage"
+	"github.com/go-nostr/nostr/message/okmessage"
+	"github.com/go-nostr/nostr/relay/store"
+	"github.com/go-nostr/nostr/subscriptionid"
+	"nhooyr.io/websocket"
+)
+
+// handleMessage implements the NIP-01 and NIP-42 protocols for a message
+// received on the connection, replying to the connection that sent it.
+func (rl *Relay) handleMessage(ctx context.Context, c *Conn, env message.Envelope) {
+	switch env := env.(type) {
+	case *message.AuthEnvelope:
+		rl.handleAuth(ctx, c, env)
+	case *message.EventEnvelope:
+		rl.handleEvent(ctx, c, env.Event)
+	case *message.ReqEnvelope:
+		rl.handleReq(ctx, c, env)
+	case *message.CloseEnvelope:
+		c.unsubscribe(env.SubscriptionID)
+	case *message.CountEnvelope:
+		rl.handleCount(ctx, c, env)
+	}
+}
+
+// handleAuth checks the authentication event answering the challenge of the
+// connection and marks the connection as authenticated with its public key.
+func (rl *Relay) handleAuth(ctx context.Context, c *Conn, env *message.AuthEnvelope) {
+	if env.Event == nil {
+		return
+	}
+	if err := clientauthenticationevent.Check(env.Event, c.relayURL, c.challenge); err != nil {
+		rl.reply(ctx, c, okmessage.New(env.Event.ID, false, okmessage.Reason(okmessage.PrefixInvalid, err.Error())))
+		return
+	}
+	c.authenticate(env.Event.PubKey)
+	rl.reply(ctx, c, okmessage.New(env.Event.ID, true, ""))
+}
+
+// handleEvent verifies the event, checks it against the limitations and the
+// kinds requiring authentication, runs the RejectEvent hooks and stores the
+// event, answers with an OK message and broadcasts the event to the matching
+// subscriptions. Ephemeral events are broadcast without being stored.
+func (rl *Relay) handleEvent(ctx context.Context, c *Conn, evt *event.Event) {
+	if err := evt.Verify(); err != nil {
+		rl.reply(ctx, c, okmessage.New(evt.ID, false, okmessage.Reason(okmessage.PrefixInvalid, err.Error())))
+		return
+	}
+	reason := rl.Limitations.checkEvent(c, evt)
+	if reason == "" && c.PubKey() == "" && containsKind(rl.AuthRequiredKinds, evt.Kind) {
+		reason = okmessage.Reason(okmessage.PrefixAuthRequired, "authentication is required to publish this kind")
+	}
+	if reason == "" {
+		reason = rl.rejectEvent(ctx, c, evt)
+	}
+	if reason != "" {
+		rl.challengeIfRequired(ctx, c, reason)
+		rl.reply(ctx, c, okmessage.New(evt.ID, false, reason))
+		return
+	}
+	var err error
+	switch {
+	case event.IsEphemeral(evt.Kind):
+	case event.IsReplaceable(evt.Kind), event.IsParameterizedReplaceable(evt.Kind):
+		err = rl.Store.Replace(ctx, evt)
+	default:
+		err = rl.Store.Save(ctx, evt)
+	}
+	switch {
+	case errors.Is(err, store.ErrDuplicate):
+		rl.reply(ctx, c, okmessage.New(evt.ID, true, okmessage.Reason(okmessage.PrefixDuplicate, err.Error())))
+		return
+	case errors.Is(err, store.ErrOutdated):
+		rl.reply(ctx, c, okmessage.New(evt.ID, false, okmessage.Reason(okmessage.PrefixDuplicate, err.Error())))
+		return
+	case err != nil:
+		go rl.errFn(err)
+		rl.reply(ctx, c, okmessage.New(evt.ID, false, okmessage.Reason(okmessage.PrefixError, "could not save event")))
+		return
+	}
+	rl.reply(ctx, c, okmessage.New(evt.ID, true, ""))
+	if !event.IsEphemeral(evt.Kind) {
+		rl.eventSaved(ctx, c, evt)
+	}
+	rl.broadcast(evt)
+}
+
+// handleReq checks the request and registers the subscription, then sends the
+// stored events matching its filters followed by an EOSE message. Rejected
+// subscriptions are answered with a CLOSED message.
+func (rl *Relay) handleReq(ctx context.Context, c *Conn, env *message.ReqEnvelope) {
+	if err := subscriptionid.Validate(env.SubscriptionID); err != nil {
+		rl.reply(ctx, c, noticemessage.New(okmessage.Reason(okmessage.PrefixInvalid, err.Error())))
+		return
+	}
+	if reason := rl.checkFilters(ctx, c, env.SubscriptionID, env.Filters, true); reason != "" {
+		c.unsubscribe(env.SubscriptionID)
+		rl.reply(ctx, c, closedmessage.New(env.SubscriptionID, reason))
+		return
+	}
+	c.subscribe(env.SubscriptionID, env.Filters)
+	events, err := rl.Store.Query(ctx, env.Filters)
+	if err != nil {
+		go rl.errFn(err)
+		c.unsubscribe(env.SubscriptionID)
+		rl.reply(ctx, c, closedmessage.New(env.SubscriptionID, okmessage.Reason(okmessage.PrefixError, "could not query events")))
+		return
+	}
+	for _, evt := range events {
+		msg, err := eventmessage.New(env.SubscriptionID, evt)
+		if err != nil {
+			go rl.errFn(err)
+			continue
+		}
+		rl.reply(ctx, c, msg)
+	}
+	rl.reply(ctx, c, eosemessage.New(env.SubscriptionID))
+}
+
+// handleCount checks the request and answers with the number of stored events
+// matching the filters.
+func (rl *Relay) handleCount(ctx context.Context, c *Conn, env *message.CountEnvelope) {
+	if err := subscriptionid.Validate(env.SubscriptionID); err != nil {
+		rl.reply(ctx, c, noticemessage.New(okmessage.Reason(okmessage.PrefixInvalid, err.Error())))
+		return
+	}
+	if reason := rl.checkFilters(ctx, c, env.SubscriptionID, env.Filters, false); reason != "" {
+		rl.reply(ctx, c, closedmessage.New(env.SubscriptionID, reason))
+		return
+	}
+	count, err := rl.Store.Count(ctx, env.Filters)
+	if err != nil {
+		go rl.errFn(err)
+		rl.reply(ctx, c, closedmessage.New(env.SubscriptionID, okmessage.Reason(okmessage.PrefixError, "could not count events")))
+		return
+	}
+	rl.reply(ctx, c, (&message.CountEnvelope{SubscriptionID: env.SubscriptionID, Count: &count}).Message())
+}
+
+// checkFilters checks the filters of a request against the limitations and
+// the kinds requiring authentication, then runs the RejectFilter hooks. It
+// returns the reason of the rejection, or an empty string.
+func (rl *Relay) checkFilters(ctx context.Context, c *Conn, subscriptionID string, filters message.Filters, subscribe bool) string {
+	reason := rl.Limitations.checkFilters(c, subscriptionID, filters, subscribe)
+	if reason == "" && c.PubKey() == "" && len(rl.AuthRequiredKinds) > 0 {
+		for _, f := range filters {
+			if requestsKinds(f, rl.AuthRequiredKinds) {
+				reason = okmessage.Reason(okmessage.PrefixAuthRequired, "authentication is required to request this kind")
+				break
+			}
+		}
+	}
+	if reason == "" {
+		reason = rl.rejectFilters(ctx, c, filters)
+	}
+	rl.challengeIfRequired(ctx, c, reason)
+	return reason
+}
+
+// challengeIfRequired sends the challenge of the connection when the reason
+// of a rejection asks the client to authenticate.
+func (rl *Relay) challengeIfRequired(ctx context.Context, c *Conn, reason string) {
+	if prefix, _ := okmessage.ParseReason(reason); prefix == okmessage.PrefixAuthRequired {
+		rl.challenge(ctx, c)
+	}
+}
+
+// challenge sends the challenge of the connection, once.
+func (rl *Relay) challenge(ctx context.Context, c *Conn) {
+	if c.markChallenged() {
+		rl.reply(ctx, c, authmessage.New(c.challenge, nil))
+	}
+}
+
+// broadcast sends the event to every subscription matching it. Events are
+// buffered per connection and written with the context of the subscriber, so
+// that slow subscribers do not stall the publisher. Subscribers whose buffer
+// is full are disconnected.
+func (rl *Relay) broadcast(evt *event.Event) {
+	rl.mu.Lock()
+	conns := make([]*Conn, 0, len(rl.connMap))
+	for c := range rl.connMap {
+		conns = append(conns, c)
+	}
+	rl.mu.Unlock()
+	for _, c := range conns {
+		for _, id := range c.matches(evt) {
+			msg, err := eventmessage.New(id, evt)
+			if err != nil {
+				go rl.errFn(err)
+				continue
+			}
+			if !c.enqueue(msg) {
+				go rl.errFn(fmt.Errorf("%w: %s", ErrSlowConnection, c.remoteAddr))
+				go c.ws.Close(websocket.StatusPolicyViolation, "too slow to receive events")
+				break
+			}
+		}
+	}
+}
+
+// reply sends the message to the connection, reporting failures to the error
+// handler.
+func (rl *Relay) reply(ctx context.Context, c *Conn, msg message.Message) {
+	if err := c.Send(ctx, msg); err != nil {
+		go rl.errFn(err)
+	}
+}
+
+// containsKind reports whether the kind is in the list.
+func containsKind(kinds []int, kind int) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// requestsKinds reports whether the filter may match events of any of the
+// kinds, which is the case of filters without kinds.
+func requestsKinds(f *message.Filter, kinds []int) bool {
+	if len(f.Kinds) == 0 {
+		return true
+	}
+	for _, k := range f.Kinds {
+		if containsKind(kinds, k) {
+			return true
+		}
+	}
+	return false
+}
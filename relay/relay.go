@@ -3,11 +3,18 @@ package relay
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/go-nostr/nostr/message"
+	"github.com/go-nostr/nostr/message/noticemessage"
+	"github.com/go-nostr/nostr/message/okmessage"
+	"github.com/go-nostr/nostr/relay/store"
+	"github.com/go-nostr/nostr/relay/store/memorystore"
 	"nhooyr.io/websocket"
 )
 
@@ -15,7 +22,8 @@ import (
 // it will create default options. If the Origin in options is not set, it sets it to "*".
 // This function also initializes a map for connections, sets error and message handlers
 // to default functions, and sets two HTTP handlers for ".well-known/nostr.json" and "/"
-// routes.
+// routes. If the Store in options is not set, events are kept in memory, and if the supported NIPs are not
+// set, they default to the NIPs implemented by the relay.
 func New(opt *Options) *Relay {
 	if opt == nil {
 		opt = new(Options)
@@ -23,17 +31,23 @@ func New(opt *Options) *Relay {
 	if opt.Origin == "" {
 		opt.Origin = "*"
 	}
+	if opt.SupportedNIPs == nil {
+		opt.SupportedNIPs = []int{1, 11, 13, 42, 45}
+	}
+	if opt.Store == nil {
+		opt.Store = memorystore.New()
+	}
 	rl := &Relay{
 		Options: opt,
 
-		connMap: make(map[*websocket.Conn]struct{}),
+		connMap: make(map[*Conn]struct{}),
 		errFn: func(err error) {
 			fmt.Printf("No error handler registered")
 		},
-		msgFn: func(msg message.Message) {
-			fmt.Printf("No message handler registered.")
-		},
-		mux: new(http.ServeMux),
+		connectFn:    func(ctx context.Context, c *Conn) {},
+		disconnectFn: func(ctx context.Context, c *Conn) {},
+		msgFn:        func(ctx context.Context, c *Conn, msg message.Message) {},
+		mux:          new(http.ServeMux),
 	}
 	rl.mux.HandleFunc("/.well-known/nostr.json", rl.getInternetIdentifier)
 	rl.mux.HandleFunc("/", rl.getIndex)
@@ -41,18 +55,27 @@ func New(opt *Options) *Relay {
 }
 
 // Options holds the configuration options for a Relay instance. This includes the name,
-// description, public key, contact, origin, supported NIPs, software, version, and limitations
-// for the relay instance.
+// description, public key, contact, origin, supported NIPs, software, version, limitations and fees
+// advertised in the information document of the relay instance, the public URL used to check NIP-42 authentications and the kinds requiring
+// authentication, as well as the Store used to persist events and the policy hooks run in order on
+// incoming events and filters.
 type Options struct {
-	Name          string
-	Description   string
-	PubKey        string
-	Contact       string
-	Origin        string
-	SupportedNIPs []int
-	Software      string
-	Version       string
-	Limitations   *Limitations
+	Name              string
+	Description       string
+	PubKey            string
+	Contact           string
+	Origin            string
+	SupportedNIPs     []int
+	Software          string
+	Version           string
+	Limitations       *Limitations
+	Fees              *Fees
+	URL               string
+	AuthRequiredKinds []int
+	Store             store.Store
+	RejectEvent       []RejectEventFunc
+	RejectFilter      []RejectFilterFunc
+	OnEventSaved      []EventSavedFunc
 }
 
 // Relay represents a websocket relay server. It holds options, a map of connections, handlers
@@ -60,15 +83,28 @@ type Options struct {
 type Relay struct {
 	*Options
 
-	connMap               map[*websocket.Conn]struct{}
+	connMap               map[*Conn]struct{}
+	connectFn             func(context.Context, *Conn)
+	disconnectFn          func(context.Context, *Conn)
 	errFn                 func(error)
 	informationDocumentFn func() (*InformationDocument, error)
 	internetIdentiferFn   func(string) (*InternetIdentifier, error)
-	msgFn                 func(message.Message)
+	msgFn                 func(context.Context, *Conn, message.Message)
 	mu                    sync.Mutex
 	mux                   *http.ServeMux
 }
 
+// HandleConnectFunc registers a function that will be called when a client connects, before any of its
+// messages are handled. The context is canceled when the client disconnects.
+func (rl *Relay) HandleConnectFunc(fn func(ctx context.Context, c *Conn)) {
+	rl.connectFn = fn
+}
+
+// HandleDisconnectFunc registers a function that will be called after a client disconnects.
+func (rl *Relay) HandleDisconnectFunc(fn func(ctx context.Context, c *Conn)) {
+	rl.disconnectFn = fn
+}
+
 // HandleErrorFunc registers a function that will handle errors. This function is called when an error
 // occurs.
 func (rl *Relay) HandleErrorFunc(fn func(error)) {
@@ -87,9 +123,11 @@ func (rl *Relay) HandleInternetIdentifierFunc(fn func(string) (*InternetIdentifi
 	rl.internetIdentiferFn = fn
 }
 
-// HandleMessageFunc registers a function that will handle messages. This function is called with a
-// Message as a parameter whenever a message is received.
-func (rl *Relay) HandleMessageFunc(fn func(message.Message)) {
+// HandleMessageFunc registers a function that will handle messages. This function is called with the
+// Conn of the sender and the Message whenever a message is received, in addition to the built-in NIP-01
+// handling. Replies can be sent to the sender with Conn.Send. Messages of the NIP-01 types carry the typed values of
+// their envelope, such as *event.Event and *message.Filter, and other messages carry the decoded JSON values.
+func (rl *Relay) HandleMessageFunc(fn func(ctx context.Context, c *Conn, msg message.Message)) {
 	rl.msgFn = fn
 }
 
@@ -102,8 +140,8 @@ func (rl *Relay) SendMessage(ctx context.Context, msg message.Message) {
 	}
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
-	for conn := range rl.connMap {
-		go conn.Write(ctx, websocket.MessageText, data)
+	for c := range rl.connMap {
+		go c.ws.Write(ctx, websocket.MessageText, data)
 	}
 }
 
@@ -117,21 +155,34 @@ func (rl *Relay) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // If the upgrade is successful, the connection is added to the active connections map and a goroutine
 // is started to listen for messages on the connection.
 func (rl *Relay) acceptConnection(w http.ResponseWriter, r *http.Request) {
-	conn, err := websocket.Accept(w, r, nil)
+	ws, err := websocket.Accept(w, r, nil)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	if rl.Limitations != nil && rl.Limitations.MaxMessageLength > 0 {
+		ws.SetReadLimit(2 * int64(rl.Limitations.MaxMessageLength))
+	}
+	c, err := newConn(ws, rl.relayURL(r), r.RemoteAddr)
+	if err != nil {
+		go rl.errFn(err)
+		ws.Close(websocket.StatusInternalError, "internal error")
+		return
+	}
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
-	rl.connMap[conn] = struct{}{}
-	go rl.listenConnection(context.Background(), conn)
+	rl.connMap[c] = struct{}{}
+	go rl.listenConnection(context.Background(), c)
 }
 
-// getIndex handles the root route ("/"). If the "Accept" header is "application/nostr+json", it serves
-// the information document. Otherwise, it attempts to upgrade the connection to a websocket.
+// getIndex handles the root route ("/"). It answers CORS preflight requests, serves the information document if
+// the "Accept" header is "application/nostr+json" and otherwise attempts to upgrade the connection to a websocket.
 func (rl *Relay) getIndex(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Accept") == "application/nostr+json" {
+	if r.Method == http.MethodOptions {
+		rl.writePreflight(w)
+		return
+	}
+	if strings.Contains(r.Header.Get("Accept"), "application/nostr+json") {
 		rl.getInformationDocument(w, r)
 		return
 	}
@@ -139,57 +190,114 @@ func (rl *Relay) getIndex(w http.ResponseWriter, r *http.Request) {
 }
 
 // getInformationDocument is an internal function that handles the generation of an information document.
-// The function uses the registered information document function to generate the document, then writes it
-// to the HTTP response in JSON format.
+// The function uses the registered information document function, or the document built from the options,
+// replaces its limitations with the limitations enforced by the relay, then writes it to the HTTP response in
+// JSON format.
 func (rl *Relay) getInformationDocument(w http.ResponseWriter, r *http.Request) {
-	informationDocument, err := rl.informationDocumentFn()
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
-		return
+	fn := rl.informationDocumentFn
+	if fn == nil {
+		fn = rl.defaultInformationDocument
 	}
-	data, err := json.Marshal(informationDocument)
+	informationDocument, err := fn()
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		rl.writeError(w, http.StatusInternalServerError, err)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
-	w.Header().Add("Access-Control-Allow-Origin", rl.Origin)
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(data)
+	doc := *informationDocument
+	doc.Limitations = rl.Limitations
+	rl.writeJSON(w, "application/nostr+json", doc)
 }
 
 // getInternetIdentifier handles the "/.well-known/nostr.json" route. It retrieves the internet identifier
 // associated with the name query parameter using the registered internet identifier function, then writes it
-// to the HTTP response in JSON format.
+// to the HTTP response in JSON format. Without a registered function it answers with a not found status.
 func (rl *Relay) getInternetIdentifier(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		rl.writePreflight(w)
+		return
+	}
+	if rl.internetIdentiferFn == nil {
+		rl.writeError(w, http.StatusNotFound, fmt.Errorf("internet identifiers are not supported"))
+		return
+	}
 	name := r.URL.Query().Get("name")
 	internetIdentifier, err := rl.internetIdentiferFn(name)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		rl.writeError(w, http.StatusInternalServerError, err)
 		return
 	}
-	data, err := json.Marshal(internetIdentifier)
+	rl.writeJSON(w, "application/json", internetIdentifier)
+}
+
+// defaultInformationDocument builds the information document from the options of the relay.
+func (rl *Relay) defaultInformationDocument() (*InformationDocument, error) {
+	return &InformationDocument{
+		Name:          rl.Name,
+		Description:   rl.Description,
+		PubKey:        rl.PubKey,
+		Contact:       rl.Contact,
+		SupportedNIPs: rl.SupportedNIPs,
+		Software:      rl.Software,
+		Version:       rl.Version,
+		Limitations:   rl.Limitations,
+		Fees:          rl.Fees,
+	}, nil
+}
+
+// writeCORS writes the CORS headers allowing browsers to read the responses of the relay.
+func (rl *Relay) writeCORS(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", rl.Origin)
+	w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+}
+
+// writeError writes the error as a plain text response with the given status.
+func (rl *Relay) writeError(w http.ResponseWriter, status int, err error) {
+	rl.writeCORS(w)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(err.Error()))
+}
+
+// writeJSON writes the value as a JSON response with the given content type.
+func (rl *Relay) writeJSON(w http.ResponseWriter, contentType string, v any) {
+	data, err := json.Marshal(v)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		rl.writeError(w, http.StatusInternalServerError, err)
 		return
 	}
+	rl.writeCORS(w)
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(http.StatusOK)
-	w.Header().Add("Access-Control-Allow-Origin", rl.Origin)
-	w.Header().Add("Content-Type", "application/json")
 	w.Write(data)
 }
 
+// writePreflight answers a CORS preflight request.
+func (rl *Relay) writePreflight(w http.ResponseWriter) {
+	rl.writeCORS(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // listenConnection is an internal function that listens for messages on a websocket connection. It reads messages
-// from the connection, decodes them into Message objects, and dispatches them to the registered message handler function.
-// If an error occurs during this process, it calls the registered error handler function.
-func (rl *Relay) listenConnection(ctx context.Context, conn *websocket.Conn) {
-	defer rl.removeConnection(conn)
+// from the connection, parses each of them once into an envelope handled according to NIP-01, and dispatches the
+// Message of the envelope to the registered message handler function. Messages that do not parse are decoded as plain
+// JSON. If an error occurs during this process, it calls the registered error handler function. Malformed messages
+// and messages longer than the maximum message length are answered with a NOTICE message, and messages longer than
+// twice that length close the connection. Messages of unknown types are left to the registered message handler.
+func (rl *Relay) listenConnection(ctx context.Context, c *Conn) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		rl.removeConnection(c)
+		rl.disconnectFn(context.Background(), c)
+	}()
+	go rl.writeConnection(ctx, c)
+	rl.connectFn(ctx, c)
+	if rl.Limitations != nil && rl.Limitations.AuthRequired {
+		rl.challenge(ctx, c)
+	}
 	for {
-		typ, rdr, err := conn.Reader(ctx)
+		typ, rdr, err := c.ws.Reader(ctx)
 		if err != nil {
 			go rl.errFn(err)
 			return
@@ -198,25 +306,91 @@ func (rl *Relay) listenConnection(ctx context.Context, conn *websocket.Conn) {
 			go rl.errFn(fmt.Errorf("unsupported message type"))
 			return
 		}
-		var msg message.Message
-		if err := json.NewDecoder(rdr).Decode(&msg); err != nil {
+		data, err := io.ReadAll(rdr)
+		if err != nil {
 			go rl.errFn(err)
 			return
 		}
+		if reason := rl.Limitations.checkMessageLength(len(data)); reason != "" {
+			rl.reply(ctx, c, noticemessage.New(reason))
+			continue
+		}
+		var msg message.Message
+		env, err := message.Parse(data)
+		if err == nil {
+			msg = env.Message()
+		} else if err := msg.Unmarshal(data); err != nil {
+			rl.reply(ctx, c, noticemessage.New("invalid message: "+err.Error()))
+			continue
+		}
+		if err == nil && !rl.checkProofOfWork(ctx, c, env) {
+			continue
+		}
 		select {
 		case <-ctx.Done():
 			return
 		default:
-			go rl.msgFn(msg)
+			go rl.msgFn(ctx, c, msg)
 		}
+		switch {
+		case errors.Is(err, message.ErrUnknownType):
+		case err != nil:
+			rl.reply(ctx, c, noticemessage.New(err.Error()))
+		default:
+			rl.handleMessage(ctx, c, env)
+		}
+	}
+}
+
+// writeConnection is an internal function that writes the events broadcast to a connection, from its send buffer,
+// until the context of the connection is done.
+func (rl *Relay) writeConnection(ctx context.Context, c *Conn) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-c.out:
+			rl.reply(ctx, c, msg)
+		}
+	}
+}
+
+// checkProofOfWork is an internal function that checks EVENT messages against the minimum proof-of-work difficulty
+// of the relay. Events below the minimum are answered with an OK message carrying the "pow:" prefix and false is
+// returned so the message is not dispatched.
+func (rl *Relay) checkProofOfWork(ctx context.Context, c *Conn, env message.Envelope) bool {
+	if rl.Limitations == nil || rl.Limitations.MinPowDifficulty <= 0 {
+		return true
+	}
+	eventEnv, ok := env.(*message.EventEnvelope)
+	if !ok {
+		return true
+	}
+	evt := eventEnv.Event
+	if err := evt.CheckPoW(rl.Limitations.MinPowDifficulty); err != nil {
+		rl.reply(ctx, c, okmessage.New(evt.ID, false, okmessage.Reason(okmessage.PrefixPoW, err.Error())))
+		return false
+	}
+	return true
+}
+
+// relayURL returns the URL clients use to connect to the relay, which is the URL option or, if it is not set, the
+// URL derived from the request.
+func (rl *Relay) relayURL(r *http.Request) string {
+	if rl.URL != "" {
+		return rl.URL
+	}
+	if r.TLS != nil {
+		return "wss://" + r.Host + r.URL.Path
 	}
+	return "ws://" + r.Host + r.URL.Path
 }
 
 // removeConnection is an internal function that removes a websocket connection from the active connections map.
 // It also closes the connection with a normal closure status.
-func (rl *Relay) removeConnection(conn *websocket.Conn) {
+func (rl *Relay) removeConnection(c *Conn) {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
-	delete(rl.connMap, conn)
-	conn.Close(websocket.StatusNormalClosure, "closing connection")
+	delete(rl.connMap, c)
+	c.Close("closing connection")
 }
@@ -2,17 +2,25 @@ package relay_test
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-nostr/nostr/client"
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/event/clientauthenticationevent"
 	"github.com/go-nostr/nostr/message"
 	"github.com/go-nostr/nostr/message/eosemessage"
+	"github.com/go-nostr/nostr/message/noticemessage"
 	"github.com/go-nostr/nostr/message/okmessage"
 	"github.com/go-nostr/nostr/relay"
+	"github.com/go-nostr/nostr/subscriptionid"
+	"github.com/go-nostr/nostr/tag"
+	"nhooyr.io/websocket"
 )
 
 func Test_New(t *testing.T) {
@@ -151,21 +159,21 @@ func TestRelay_SendMessage(t *testing.T) {
 			name: "SHOULD send EOSE message",
 			args: args{
 				ctx: context.TODO(),
-				msg: eosemessage.New(),
+				msg: eosemessage.New(subscriptionid.New()),
 			},
 		},
 		{
 			name: "SHOULD send EVENT message",
 			args: args{
 				ctx: context.TODO(),
-				msg: eosemessage.New(),
+				msg: eosemessage.New(subscriptionid.New()),
 			},
 		},
 		{
 			name: "SHOULD send NOTICE message",
 			args: args{
 				ctx: context.TODO(),
-				msg: eosemessage.New(),
+				msg: eosemessage.New(subscriptionid.New()),
 			},
 		},
 		{
@@ -181,7 +189,9 @@ func TestRelay_SendMessage(t *testing.T) {
 		rl := relay.New(nil)
 		ts := httptest.NewServer(rl)
 		defer ts.Close()
-		cl.Connect(context.TODO(), ts.URL)
+		if err := cl.Connect(context.TODO(), ts.URL); err != nil {
+			t.Fatal(err)
+		}
 		cl.HandleErrorFunc(func(err error) {
 			t.Error(err)
 		})
@@ -273,3 +283,678 @@ func TestRelay_ServeHTTP(t *testing.T) {
 		})
 	}
 }
+
+func TestRelay_MinPowDifficulty(t *testing.T) {
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey, _ := signer.GetPublicKey(context.TODO())
+	tests := []struct {
+		name      string
+		mine      int
+		malformed bool
+		expect    bool
+	}{
+		{
+			name:   "SHOULD reject event below minimum difficulty",
+			mine:   0,
+			expect: false,
+		},
+		{
+			name:   "SHOULD dispatch event meeting minimum difficulty",
+			mine:   16,
+			expect: true,
+		},
+		{
+			name:      "SHOULD answer malformed event with notice",
+			malformed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+			defer cancel()
+			msgCh := make(chan message.Message, 1)
+			rl := relay.New(&relay.Options{
+				Limitations: &relay.Limitations{
+					MinPowDifficulty: 16,
+				},
+			})
+			rl.HandleErrorFunc(func(err error) {})
+			rl.HandleMessageFunc(func(ctx context.Context, c *relay.Conn, msg message.Message) {
+				msgCh <- msg
+			})
+			ts := httptest.NewServer(rl)
+			defer ts.Close()
+			evt := event.New(1, "content")
+			evt.PubKey = pubKey
+			if tt.mine > 0 {
+				if err := event.Mine(ctx, evt, tt.mine); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := evt.SignWith(ctx, signer); err != nil {
+				t.Fatal(err)
+			}
+			conn, _, err := websocket.Dial(ctx, ts.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close(websocket.StatusNormalClosure, "")
+			data, _ := message.New("EVENT", evt).Marshal()
+			if tt.malformed {
+				data = []byte(`["EVENT","malformed"]`)
+			}
+			if err := conn.Write(ctx, websocket.MessageText, data); err != nil {
+				t.Fatal(err)
+			}
+			if tt.expect {
+				select {
+				case msg := <-msgCh:
+					t.Logf("got %v", msg)
+				case <-ctx.Done():
+					t.Fatal(ctx.Err())
+				}
+				return
+			}
+			_, data, err = conn.Read(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got message.Message
+			if err := got.Unmarshal(data); err != nil {
+				t.Fatal(err)
+			}
+			if tt.malformed {
+				if len(got) != 2 || got[0] != noticemessage.Type {
+					t.Fatalf("expected NOTICE, got %v", got)
+				}
+				t.Logf("got %v", got)
+				return
+			}
+			if len(got) != 4 || got[0] != okmessage.Type || got[1] != evt.ID || got[2] != false {
+				t.Fatalf("expected OK false for %v, got %v", evt.ID, got)
+			}
+			if status, _ := got[3].(string); !strings.HasPrefix(status, "pow: ") {
+				t.Errorf("expected pow: prefix, got %v", status)
+			}
+			t.Logf("got %v", got)
+		})
+	}
+}
+
+func TestRelay_Protocol(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rl := relay.New(nil)
+	rl.HandleErrorFunc(func(err error) {})
+	ts := httptest.NewServer(rl)
+	defer ts.Close()
+	sign := func(kind int, content string) *event.Event {
+		evt := event.New(kind, content)
+		if err := evt.SignWith(ctx, signer); err != nil {
+			t.Fatal(err)
+		}
+		return evt
+	}
+	stored := sign(1, "stored")
+	subscriber := dial(ctx, t, ts.URL)
+	publisher := dial(ctx, t, ts.URL)
+
+	t.Run("SHOULD accept event", func(t *testing.T) {
+		write(ctx, t, publisher, message.New("EVENT", stored))
+		expect(ctx, t, publisher, message.Message{"OK", stored.ID, true, ""})
+	})
+
+	t.Run("SHOULD report duplicate event", func(t *testing.T) {
+		write(ctx, t, publisher, message.New("EVENT", stored))
+		expect(ctx, t, publisher, message.Message{"OK", stored.ID, true, "duplicate: event already stored"})
+	})
+
+	t.Run("SHOULD reject invalid event", func(t *testing.T) {
+		evt := sign(1, "tampered")
+		evt.Content = "changed"
+		write(ctx, t, publisher, message.New("EVENT", evt))
+		got := read(ctx, t, publisher)
+		if len(got) != 4 || got[2] != false || !strings.HasPrefix(got[3].(string), "invalid: ") {
+			t.Errorf("expected OK false with invalid: prefix, got %v", got)
+		}
+	})
+
+	t.Run("SHOULD answer subscription with stored events and EOSE", func(t *testing.T) {
+		write(ctx, t, subscriber, message.New("REQ", "sub", map[string]any{"kinds": []int{1}}))
+		expect(ctx, t, subscriber, message.Message{"EVENT", "sub", mustMap(t, stored)})
+		expect(ctx, t, subscriber, message.Message{"EOSE", "sub"})
+	})
+
+	t.Run("SHOULD fan out new events to matching subscriptions only", func(t *testing.T) {
+		ignored := sign(7, "+")
+		write(ctx, t, publisher, message.New("EVENT", ignored))
+		expect(ctx, t, publisher, message.Message{"OK", ignored.ID, true, ""})
+		evt := sign(1, "live")
+		write(ctx, t, publisher, message.New("EVENT", evt))
+		expect(ctx, t, publisher, message.Message{"OK", evt.ID, true, ""})
+		expect(ctx, t, subscriber, message.Message{"EVENT", "sub", mustMap(t, evt)})
+	})
+
+	t.Run("SHOULD count stored events", func(t *testing.T) {
+		write(ctx, t, subscriber, message.New("COUNT", "count", map[string]any{"kinds": []int{1}}))
+		expect(ctx, t, subscriber, message.Message{"COUNT", "count", map[string]any{"count": float64(2)}})
+	})
+
+	t.Run("SHOULD stop sending events after CLOSE", func(t *testing.T) {
+		write(ctx, t, subscriber, message.New("CLOSE", "sub"))
+		evt := sign(1, "after close")
+		write(ctx, t, publisher, message.New("EVENT", evt))
+		expect(ctx, t, publisher, message.Message{"OK", evt.ID, true, ""})
+		write(ctx, t, subscriber, message.New("REQ", "other", map[string]any{"ids": []string{evt.ID}}))
+		expect(ctx, t, subscriber, message.Message{"EVENT", "other", mustMap(t, evt)})
+		expect(ctx, t, subscriber, message.Message{"EOSE", "other"})
+	})
+
+	t.Run("SHOULD send NOTICE for invalid message", func(t *testing.T) {
+		write(ctx, t, publisher, message.New("REQ"))
+		got := read(ctx, t, publisher)
+		if len(got) != 2 || got[0] != "NOTICE" {
+			t.Errorf("expected NOTICE, got %v", got)
+		}
+		t.Logf("got %v", got)
+	})
+
+	t.Run("SHOULD reject oversized subscription id without crashing", func(t *testing.T) {
+		id := strings.Repeat("x", 65)
+		write(ctx, t, subscriber, message.New("REQ", id, map[string]any{"kinds": []int{1}}))
+		expect(ctx, t, subscriber, message.Message{"NOTICE", "invalid: invalid subscription id"})
+		write(ctx, t, subscriber, message.New("COUNT", id, map[string]any{"kinds": []int{1}}))
+		expect(ctx, t, subscriber, message.Message{"NOTICE", "invalid: invalid subscription id"})
+		write(ctx, t, subscriber, message.New("COUNT", "count", map[string]any{"kinds": []int{7}}))
+		expect(ctx, t, subscriber, message.Message{"COUNT", "count", map[string]any{"count": float64(1)}})
+	})
+}
+
+// dial opens a websocket connection to the relay.
+func dial(ctx context.Context, t *testing.T, u string) *websocket.Conn {
+	conn, _, err := websocket.Dial(ctx, u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close(websocket.StatusNormalClosure, "") })
+	return conn
+}
+
+// expect reads the next message and compares it to the expected message.
+func expect(ctx context.Context, t *testing.T, conn *websocket.Conn, msg message.Message) {
+	t.Helper()
+	if got := read(ctx, t, conn); !reflect.DeepEqual(msg, got) {
+		t.Errorf("expected %v, got %v", msg, got)
+	}
+}
+
+// mustMap converts the event to its decoded JSON representation.
+func mustMap(t *testing.T, evt *event.Event) map[string]any {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// read reads the next message from the connection.
+func read(ctx context.Context, t *testing.T, conn *websocket.Conn) message.Message {
+	t.Helper()
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg message.Message
+	if err := msg.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+// write writes the message to the connection.
+func write(ctx context.Context, t *testing.T, conn *websocket.Conn, msg message.Message) {
+	t.Helper()
+	data, err := msg.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Write(ctx, websocket.MessageText, data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRelay_Conn(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	type key struct{}
+	disconnected := make(chan *relay.Conn, 1)
+	rl := relay.New(nil)
+	rl.HandleErrorFunc(func(err error) {})
+	rl.HandleConnectFunc(func(ctx context.Context, c *relay.Conn) {
+		c.SetValue(key{}, "state")
+	})
+	rl.HandleMessageFunc(func(ctx context.Context, c *relay.Conn, msg message.Message) {
+		if msg[0] != "PING" {
+			return
+		}
+		subs := make([]string, 0)
+		for id := range c.Subscriptions() {
+			subs = append(subs, id)
+		}
+		c.Send(ctx, message.New("PONG", c.Value(key{}), c.RemoteAddr() != "", c.PubKey(), subs))
+	})
+	rl.HandleDisconnectFunc(func(ctx context.Context, c *relay.Conn) {
+		disconnected <- c
+	})
+	ts := httptest.NewServer(rl)
+	defer ts.Close()
+	conn := dial(ctx, t, ts.URL)
+	other := dial(ctx, t, ts.URL)
+
+	t.Run("SHOULD reply to sender only", func(t *testing.T) {
+		write(ctx, t, conn, message.New("REQ", "sub", map[string]any{}))
+		expect(ctx, t, conn, message.Message{"EOSE", "sub"})
+		write(ctx, t, conn, message.New("PING"))
+		expect(ctx, t, conn, message.Message{"PONG", "state", true, "", []any{"sub"}})
+		write(ctx, t, other, message.New("PING"))
+		expect(ctx, t, other, message.Message{"PONG", "state", true, "", []any{}})
+	})
+
+	t.Run("SHOULD call disconnect handler", func(t *testing.T) {
+		conn.Close(websocket.StatusNormalClosure, "")
+		select {
+		case c := <-disconnected:
+			if got := c.Value(key{}); got != "state" {
+				t.Errorf("expected %v, got %v", "state", got)
+			}
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	})
+}
+
+func TestRelay_Hooks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := make(chan *event.Event, 1)
+	rl := relay.New(&relay.Options{
+		RejectEvent: []relay.RejectEventFunc{
+			func(ctx context.Context, c *relay.Conn, evt *event.Event) string {
+				if evt.Content == "spam" {
+					return "no spam"
+				}
+				return ""
+			},
+			func(ctx context.Context, c *relay.Conn, evt *event.Event) string {
+				if evt.Kind == 4 {
+					return "rate-limited: slow down"
+				}
+				return ""
+			},
+		},
+		RejectFilter: []relay.RejectFilterFunc{
+			func(ctx context.Context, c *relay.Conn, f *message.Filter) string {
+				if len(f.Kinds) == 0 {
+					return "restricted: kinds are required"
+				}
+				return ""
+			},
+		},
+		OnEventSaved: []relay.EventSavedFunc{
+			func(ctx context.Context, c *relay.Conn, evt *event.Event) {
+				saved <- evt
+			},
+		},
+	})
+	rl.HandleErrorFunc(func(err error) {})
+	ts := httptest.NewServer(rl)
+	defer ts.Close()
+	conn := dial(ctx, t, ts.URL)
+	tests := []struct {
+		name    string
+		kind    int
+		content string
+		expect  string
+	}{
+		{
+			name:    "SHOULD add blocked prefix to reason without prefix",
+			kind:    1,
+			content: "spam",
+			expect:  "blocked: no spam",
+		},
+		{
+			name:    "SHOULD keep reason prefix",
+			kind:    4,
+			content: "dm",
+			expect:  "rate-limited: slow down",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evt := event.New(tt.kind, tt.content)
+			if err := evt.SignWith(ctx, signer); err != nil {
+				t.Fatal(err)
+			}
+			write(ctx, t, conn, message.New("EVENT", evt))
+			expect(ctx, t, conn, message.Message{"OK", evt.ID, false, tt.expect})
+		})
+	}
+
+	t.Run("SHOULD call saved hook for accepted event", func(t *testing.T) {
+		evt := event.New(1, "hello")
+		if err := evt.SignWith(ctx, signer); err != nil {
+			t.Fatal(err)
+		}
+		write(ctx, t, conn, message.New("EVENT", evt))
+		expect(ctx, t, conn, message.Message{"OK", evt.ID, true, ""})
+		select {
+		case got := <-saved:
+			if got.ID != evt.ID {
+				t.Errorf("expected %v, got %v", evt.ID, got.ID)
+			}
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	})
+
+	t.Run("SHOULD close rejected subscription", func(t *testing.T) {
+		write(ctx, t, conn, message.New("REQ", "sub", map[string]any{}))
+		expect(ctx, t, conn, message.Message{"CLOSED", "sub", "restricted: kinds are required"})
+	})
+}
+
+func TestRelay_Limitations(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(content string, tags ...tag.Tag) *event.Event {
+		evt := event.New(1, content, tags...)
+		if err := evt.SignWith(ctx, signer); err != nil {
+			t.Fatal(err)
+		}
+		return evt
+	}
+	limitations := &relay.Limitations{
+		MaxContentLength: 10,
+		MaxEventTags:     1,
+		MaxFilters:       2,
+		MaxLimit:         2,
+		MaxMessageLength: 1024,
+		MaxSubIDLength:   8,
+		MaxSubscriptions: 2,
+		MinPrefix:        4,
+	}
+	rl := relay.New(&relay.Options{Limitations: limitations})
+	rl.HandleErrorFunc(func(err error) {})
+	rl.HandleInformationDocumentFunc(func() (*relay.InformationDocument, error) {
+		return &relay.InformationDocument{Name: "relay", Limitations: &relay.Limitations{MaxLimit: 100}}, nil
+	})
+	ts := httptest.NewServer(rl)
+	defer ts.Close()
+	conn := dial(ctx, t, ts.URL)
+	tooManyTags := sign("tags", tag.Tag{"t", "a"}, tag.Tag{"t", "b"})
+	tooLong := sign("more than ten characters")
+	tests := []struct {
+		name   string
+		msg    message.Message
+		expect message.Message
+	}{
+		{
+			name:   "SHOULD reject message longer than maximum",
+			msg:    message.New("NOTICE", strings.Repeat("x", 1100)),
+			expect: message.Message{"NOTICE", "invalid: message is longer than 1024 bytes"},
+		},
+		{
+			name:   "SHOULD reject event with too many tags",
+			msg:    message.New("EVENT", tooManyTags),
+			expect: message.Message{"OK", tooManyTags.ID, false, "invalid: event has more than 1 tags"},
+		},
+		{
+			name:   "SHOULD reject event with content longer than maximum",
+			msg:    message.New("EVENT", tooLong),
+			expect: message.Message{"OK", tooLong.ID, false, "invalid: content is longer than 10 characters"},
+		},
+		{
+			name:   "SHOULD reject subscription id longer than maximum",
+			msg:    message.New("REQ", "123456789", map[string]any{}),
+			expect: message.Message{"CLOSED", "123456789", "invalid: subscription id is longer than 8 characters"},
+		},
+		{
+			name:   "SHOULD reject request with too many filters",
+			msg:    message.New("REQ", "filters", map[string]any{}, map[string]any{}, map[string]any{}),
+			expect: message.Message{"CLOSED", "filters", "invalid: request has more than 2 filters"},
+		},
+		{
+			name:   "SHOULD reject prefix shorter than minimum",
+			msg:    message.New("COUNT", "prefix", map[string]any{"authors": []string{"abc"}}),
+			expect: message.Message{"CLOSED", "prefix", `invalid: prefix "abc" is shorter than 4 characters`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write(ctx, t, conn, tt.msg)
+			expect(ctx, t, conn, tt.expect)
+		})
+	}
+
+	t.Run("SHOULD clamp limit to maximum", func(t *testing.T) {
+		for _, content := range []string{"one", "two", "three"} {
+			evt := sign(content)
+			write(ctx, t, conn, message.New("EVENT", evt))
+			expect(ctx, t, conn, message.Message{"OK", evt.ID, true, ""})
+		}
+		write(ctx, t, conn, message.New("REQ", "a", map[string]any{}))
+		for i := 0; i < 2; i++ {
+			if got := read(ctx, t, conn); got[0] != "EVENT" {
+				t.Fatalf("expected EVENT, got %v", got)
+			}
+		}
+		expect(ctx, t, conn, message.Message{"EOSE", "a"})
+	})
+
+	t.Run("SHOULD reject subscriptions above maximum", func(t *testing.T) {
+		write(ctx, t, conn, message.New("REQ", "b", map[string]any{"kinds": []int{0}}))
+		expect(ctx, t, conn, message.Message{"EOSE", "b"})
+		write(ctx, t, conn, message.New("REQ", "c", map[string]any{"kinds": []int{0}}))
+		expect(ctx, t, conn, message.Message{"CLOSED", "c", "blocked: more than 2 subscriptions"})
+		write(ctx, t, conn, message.New("REQ", "b", map[string]any{"kinds": []int{0}}))
+		expect(ctx, t, conn, message.Message{"EOSE", "b"})
+	})
+
+	t.Run("SHOULD require authentication", func(t *testing.T) {
+		rl := relay.New(&relay.Options{Limitations: &relay.Limitations{AuthRequired: true}})
+		rl.HandleErrorFunc(func(err error) {})
+		ts := httptest.NewServer(rl)
+		defer ts.Close()
+		conn := dial(ctx, t, ts.URL)
+		if got := read(ctx, t, conn); len(got) != 2 || got[0] != "AUTH" {
+			t.Fatalf("expected AUTH challenge, got %v", got)
+		}
+		evt := sign("hello")
+		write(ctx, t, conn, message.New("EVENT", evt))
+		expect(ctx, t, conn, message.Message{"OK", evt.ID, false, "auth-required: authentication is required to publish events"})
+		write(ctx, t, conn, message.New("REQ", "sub", map[string]any{}))
+		expect(ctx, t, conn, message.Message{"CLOSED", "sub", "auth-required: authentication is required to subscribe"})
+	})
+
+	t.Run("SHOULD advertise enforced limitations", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Add("Accept", "application/nostr+json")
+		w := httptest.NewRecorder()
+		rl.ServeHTTP(w, req)
+		var got relay.InformationDocument
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(limitations, got.Limitations) {
+			t.Errorf("expected %+v, got %+v", limitations, got.Limitations)
+		}
+	})
+}
+
+func TestRelay_Auth(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	signer, err := event.NewKeySigner("0000000000000000000000000000000000000000000000000000000000000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey, _ := signer.GetPublicKey(ctx)
+	sign := func(evt *event.Event) *event.Event {
+		if err := evt.SignWith(ctx, signer); err != nil {
+			t.Fatal(err)
+		}
+		return evt
+	}
+	authed := make(chan string, 1)
+	rl := relay.New(&relay.Options{AuthRequiredKinds: []int{4}})
+	rl.HandleErrorFunc(func(err error) {})
+	rl.HandleMessageFunc(func(ctx context.Context, c *relay.Conn, msg message.Message) {
+		if msg[0] == "PING" {
+			authed <- c.PubKey()
+		}
+	})
+	ts := httptest.NewServer(rl)
+	defer ts.Close()
+	conn := dial(ctx, t, ts.URL)
+	var challenge string
+
+	t.Run("SHOULD challenge client publishing kind requiring authentication", func(t *testing.T) {
+		evt := sign(event.New(4, "secret"))
+		write(ctx, t, conn, message.New("EVENT", evt))
+		got := read(ctx, t, conn)
+		if len(got) != 2 || got[0] != "AUTH" {
+			t.Fatalf("expected AUTH challenge, got %v", got)
+		}
+		challenge = got[1].(string)
+		expect(ctx, t, conn, message.Message{"OK", evt.ID, false, "auth-required: authentication is required to publish this kind"})
+	})
+
+	t.Run("SHOULD close subscription requesting kind requiring authentication", func(t *testing.T) {
+		write(ctx, t, conn, message.New("REQ", "dms", map[string]any{"kinds": []int{4}}))
+		expect(ctx, t, conn, message.Message{"CLOSED", "dms", "auth-required: authentication is required to request this kind"})
+	})
+
+	t.Run("SHOULD reject authentication with wrong challenge", func(t *testing.T) {
+		evt := sign(clientauthenticationevent.New(ts.URL, "wrong"))
+		write(ctx, t, conn, message.New("AUTH", evt))
+		got := read(ctx, t, conn)
+		if len(got) != 4 || got[1] != evt.ID || got[2] != false || !strings.HasPrefix(got[3].(string), "invalid: ") {
+			t.Errorf("expected OK false with invalid: prefix, got %v", got)
+		}
+	})
+
+	t.Run("SHOULD authenticate client", func(t *testing.T) {
+		evt := sign(clientauthenticationevent.New(strings.Replace(ts.URL, "http", "ws", 1), challenge))
+		write(ctx, t, conn, message.New("AUTH", evt))
+		expect(ctx, t, conn, message.Message{"OK", evt.ID, true, ""})
+		write(ctx, t, conn, message.New("PING"))
+		if got := <-authed; got != pubKey {
+			t.Errorf("expected %v, got %v", pubKey, got)
+		}
+		dm := sign(event.New(4, "secret"))
+		write(ctx, t, conn, message.New("EVENT", dm))
+		expect(ctx, t, conn, message.Message{"OK", dm.ID, true, ""})
+		write(ctx, t, conn, message.New("REQ", "dms", map[string]any{"kinds": []int{4}}))
+		expect(ctx, t, conn, message.Message{"EVENT", "dms", mustMap(t, dm)})
+		expect(ctx, t, conn, message.Message{"EOSE", "dms"})
+	})
+}
+
+func TestRelay_InformationDocument(t *testing.T) {
+	rl := relay.New(&relay.Options{
+		Name:          "relay",
+		Description:   "a relay",
+		PubKey:        strings.Repeat("a", 64),
+		Contact:       "mailto:admin@example.com",
+		SupportedNIPs: []int{1, 11},
+		Software:      "https://github.com/go-nostr/nostr",
+		Version:       "v1.0.0",
+		Limitations:   &relay.Limitations{MaxLimit: 100},
+		Fees: &relay.Fees{
+			Admission: []*relay.Fee{{Amount: 1000, Unit: "msats"}},
+		},
+	})
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		accept  string
+		status  int
+		headers map[string]string
+		body    string
+	}{
+		{
+			name:   "SHOULD serve information document built from options",
+			method: http.MethodGet,
+			target: "/",
+			accept: "application/nostr+json",
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin": "*",
+				"Content-Type":                "application/nostr+json",
+			},
+			body: `{"name":"relay","description":"a relay","pubkey":"` + strings.Repeat("a", 64) + `","contact":"mailto:admin@example.com","supported_nips":[1,11],"software":"https://github.com/go-nostr/nostr","version":"v1.0.0","limitations":{"max_limit":100},"fees":{"admission":[{"amount":1000,"unit":"msats"}]}}`,
+		},
+		{
+			name:   "SHOULD answer CORS preflight",
+			method: http.MethodOptions,
+			target: "/",
+			status: http.StatusNoContent,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":  "*",
+				"Access-Control-Allow-Headers": "Accept, Content-Type",
+				"Access-Control-Allow-Methods": "GET, OPTIONS",
+			},
+		},
+		{
+			name:   "SHOULD answer not found without internet identifier handler",
+			method: http.MethodGet,
+			target: "/.well-known/nostr.json?name=bob",
+			status: http.StatusNotFound,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin": "*",
+			},
+			body: "internet identifiers are not supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			rl.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("expected status %v, got %v", tt.status, w.Code)
+			}
+			for k, v := range tt.headers {
+				if got := w.Header().Get(k); got != v {
+					t.Errorf("expected %v header %v, got %v", k, v, got)
+				}
+			}
+			if got := w.Body.String(); got != tt.body {
+				t.Errorf("expected %v, got %v", tt.body, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,619 @@
+package boltstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/message"
+	"github.com/go-nostr/nostr/relay/store"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bucket names. Every index key ends with the inverted created_at timestamp
+// followed by the event ID, so that a forward cursor scan over a key prefix
+// visits events newest first.
+var (
+	bucketEvents     = []byte("events")      // id -> event JSON
+	bucketCreatedAt  = []byte("created_at")  // ts|id
+	bucketAuthor     = []byte("author")      // pubkey|ts|id
+	bucketKind       = []byte("kind")        // kind|ts|id
+	bucketAuthorKind = []byte("author_kind") // pubkey|kind|ts|id
+	bucketTag        = []byte("tag")         // name|hash(value)|ts|id
+	bucketAddress    = []byte("address")     // hash(address)|id
+)
+
+// Sizes of the fixed-width parts of index keys.
+const (
+	idSize   = 32
+	hashSize = 8
+	kindSize = 4
+	tsSize   = 8
+	keySize  = tsSize + idSize
+)
+
+// DefaultCompactTxSize is the default number of bytes copied per transaction
+// by Compact.
+const DefaultCompactTxSize = 64 << 20
+
+// New opens the bolt database at path, creating it if needed, and returns a
+// Store backed by it. The database must be closed with Close.
+func New(path string) (*Store, error) {
+	db, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db, path: path}, nil
+}
+
+// Store is a store.Store persisted in a single bolt database file. Events are
+// indexed by author, kind, author and kind, single-letter tag and created_at.
+type Store struct {
+	db   *bolt.DB
+	path string
+	mu   sync.RWMutex
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db.Close()
+}
+
+// Compact rewrites the database into a new file without the pages freed by
+// deleted and replaced events, then swaps it in place of the current file.
+// Other operations wait until compaction is done. The current database stays
+// in use if the new file cannot be opened or moved in place.
+func (s *Store) Compact(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp := s.path + ".compact"
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	dst, err := bolt.Open(tmp, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return err
+	}
+	if err := bolt.Compact(dst, s.db, DefaultCompactTxSize); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("compact: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	db, err := open(tmp)
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	// The new database is moved while open so the current one is only closed
+	// once the swap can no longer fail.
+	if err := os.Rename(tmp, s.path); err != nil {
+		db.Close()
+		os.Remove(tmp)
+		return err
+	}
+	old := s.db
+	s.db = db
+	return old.Close()
+}
+
+// Size returns the size of the database file in bytes.
+func (s *Store) Size() (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var size int64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		size = tx.Size()
+		return nil
+	})
+	return size, err
+}
+
+// Save stores a new event.
+func (s *Store) Save(ctx context.Context, evt *event.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		id, err := decodeHex(evt.ID, "id")
+		if err != nil {
+			return err
+		}
+		if tx.Bucket(bucketEvents).Get(id) != nil {
+			return store.ErrDuplicate
+		}
+		return insert(tx, evt)
+	})
+}
+
+// Query returns the events matching any of the filters, newest first.
+func (s *Store) Query(ctx context.Context, filters message.Filters) ([]*event.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	events := make([]*event.Event, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		seen := make(map[string]struct{})
+		for _, f := range filters {
+			if f == nil {
+				continue
+			}
+			err := query(ctx, tx, f, f.Limit, func(evt *event.Event) {
+				if _, ok := seen[evt.ID]; ok {
+					return
+				}
+				seen[evt.ID] = struct{}{}
+				events = append(events, evt)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	store.Sort(events)
+	return events, nil
+}
+
+// Delete removes the event with the given ID.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		key, err := hex.DecodeString(id)
+		if err != nil || len(key) != idSize {
+			return store.ErrNotFound
+		}
+		evt, err := get(tx, key)
+		if err != nil {
+			return err
+		}
+		if evt == nil {
+			return store.ErrNotFound
+		}
+		return remove(tx, evt)
+	})
+}
+
+// Count returns the number of events matching any of the filters. Limits are
+// ignored when counting.
+func (s *Store) Count(ctx context.Context, filters message.Filters) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seen := make(map[string]struct{})
+	err := s.db.View(func(tx *bolt.Tx) error {
+		for _, f := range filters {
+			if f == nil {
+				continue
+			}
+			err := query(ctx, tx, f, 0, func(evt *event.Event) {
+				seen[evt.ID] = struct{}{}
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(seen), err
+}
+
+// Replace stores the event and removes the older versions it replaces.
+func (s *Store) Replace(ctx context.Context, evt *event.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		id, err := decodeHex(evt.ID, "id")
+		if err != nil {
+			return err
+		}
+		if tx.Bucket(bucketEvents).Get(id) != nil {
+			return store.ErrDuplicate
+		}
+		prefix := hash(store.Address(evt))
+		var previous []*event.Event
+		c := tx.Bucket(bucketAddress).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			prev, err := get(tx, k[len(prefix):])
+			if err != nil {
+				return err
+			}
+			if prev == nil || store.Address(prev) != store.Address(evt) {
+				continue
+			}
+			if store.Newer(prev, evt) {
+				return store.ErrOutdated
+			}
+			previous = append(previous, prev)
+		}
+		for _, prev := range previous {
+			if err := remove(tx, prev); err != nil {
+				return err
+			}
+		}
+		return insert(tx, evt)
+	})
+}
+
+// indexKeys returns the keys of the event in each index bucket.
+func indexKeys(evt *event.Event) (map[string][][]byte, error) {
+	id, err := decodeHex(evt.ID, "id")
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := decodeHex(evt.PubKey, "pubkey")
+	if err != nil {
+		return nil, err
+	}
+	suffix := append(timestamp(evt.CreatedAt), id...)
+	keys := map[string][][]byte{
+		string(bucketCreatedAt):  {suffix},
+		string(bucketAuthor):     {join(pubKey, suffix)},
+		string(bucketKind):       {join(kind(evt.Kind), suffix)},
+		string(bucketAuthorKind): {join(pubKey, kind(evt.Kind), suffix)},
+	}
+	for _, t := range evt.Tags {
+		if len(t) < 2 {
+			continue
+		}
+		name, ok := t[0].(string)
+		if !ok || len(name) != 1 {
+			continue
+		}
+		if value, ok := t[1].(string); ok {
+			keys[string(bucketTag)] = append(keys[string(bucketTag)], join(tagPrefix(name, value), suffix))
+		}
+	}
+	if event.IsReplaceable(evt.Kind) || event.IsParameterizedReplaceable(evt.Kind) {
+		keys[string(bucketAddress)] = [][]byte{join(hash(store.Address(evt)), id)}
+	}
+	return keys, nil
+}
+
+// insert writes the event and its index keys.
+func insert(tx *bolt.Tx, evt *event.Event) error {
+	keys, err := indexKeys(evt)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	id := keys[string(bucketCreatedAt)][0][tsSize:]
+	if err := tx.Bucket(bucketEvents).Put(id, data); err != nil {
+		return err
+	}
+	for name, list := range keys {
+		b := tx.Bucket([]byte(name))
+		for _, k := range list {
+			if err := b.Put(k, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// remove deletes the event and its index keys.
+func remove(tx *bolt.Tx, evt *event.Event) error {
+	keys, err := indexKeys(evt)
+	if err != nil {
+		return err
+	}
+	id := keys[string(bucketCreatedAt)][0][tsSize:]
+	if err := tx.Bucket(bucketEvents).Delete(id); err != nil {
+		return err
+	}
+	for name, list := range keys {
+		b := tx.Bucket([]byte(name))
+		for _, k := range list {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// get returns the event stored under the raw ID, or nil if there is none.
+func get(tx *bolt.Tx, id []byte) (*event.Event, error) {
+	data := tx.Bucket(bucketEvents).Get(id)
+	if data == nil {
+		return nil, nil
+	}
+	evt := new(event.Event)
+	if err := json.Unmarshal(data, evt); err != nil {
+		return nil, fmt.Errorf("corrupt event %x: %w", id, err)
+	}
+	return evt, nil
+}
+
+// query calls fn for the events matching the filter, newest first, stopping
+// after limit events when limit is positive.
+func query(ctx context.Context, tx *bolt.Tx, f *message.Filter, limit int, fn func(*event.Event)) error {
+	if len(f.IDs) > 0 {
+		return queryIDs(tx, f, limit, fn)
+	}
+	scans := plan(tx, f)
+	seen := make(map[string]struct{})
+	n := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var next *scan
+		for _, sc := range scans {
+			if sc.key != nil && (next == nil || bytes.Compare(sc.suffix(), next.suffix()) < 0) {
+				next = sc
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		id := next.suffix()[tsSize:]
+		next.advance()
+		if _, ok := seen[string(id)]; ok {
+			continue
+		}
+		seen[string(id)] = struct{}{}
+		evt, err := get(tx, id)
+		if err != nil {
+			return err
+		}
+		if evt == nil || !f.Matches(evt) {
+			continue
+		}
+		fn(evt)
+		if n++; limit > 0 && n >= limit {
+			return nil
+		}
+	}
+}
+
+// queryIDs calls fn for the events matching a filter with IDs or ID prefixes,
+// looking them up directly in the events bucket.
+func queryIDs(tx *bolt.Tx, f *message.Filter, limit int, fn func(*event.Event)) error {
+	c := tx.Bucket(bucketEvents).Cursor()
+	seen := make(map[string]struct{})
+	var events []*event.Event
+	for _, v := range f.IDs {
+		prefix, err := hex.DecodeString(v[:len(v)-len(v)%2])
+		if err != nil {
+			continue
+		}
+		for k, data := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, data = c.Next() {
+			if _, ok := seen[string(k)]; ok {
+				continue
+			}
+			seen[string(k)] = struct{}{}
+			evt := new(event.Event)
+			if err := json.Unmarshal(data, evt); err != nil {
+				return fmt.Errorf("corrupt event %x: %w", k, err)
+			}
+			if f.Matches(evt) {
+				events = append(events, evt)
+			}
+		}
+	}
+	store.Sort(events)
+	for i, evt := range events {
+		if limit > 0 && i >= limit {
+			break
+		}
+		fn(evt)
+	}
+	return nil
+}
+
+// plan returns the index scans used to find the candidates of a filter,
+// preferring tag, then author and kind, then author, then kind indexes.
+func plan(tx *bolt.Tx, f *message.Filter) []*scan {
+	var prefixes [][]byte
+	var bucket []byte
+	authors := exactHex(f.Authors)
+	tags := f.TagValues()
+	switch {
+	case len(tags) > 0:
+		names := make([]string, 0, len(tags))
+		for name := range tags {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return len(tags[names[i]]) < len(tags[names[j]])
+		})
+		bucket = bucketTag
+		for _, v := range tags[names[0]] {
+			prefixes = append(prefixes, tagPrefix(names[0], v))
+		}
+	case authors != nil && len(f.Kinds) > 0:
+		bucket = bucketAuthorKind
+		for _, a := range authors {
+			for _, k := range f.Kinds {
+				prefixes = append(prefixes, join(a, kind(k)))
+			}
+		}
+	case authors != nil:
+		bucket, prefixes = bucketAuthor, authors
+	case len(f.Kinds) > 0:
+		bucket = bucketKind
+		for _, k := range f.Kinds {
+			prefixes = append(prefixes, kind(k))
+		}
+	default:
+		bucket, prefixes = bucketCreatedAt, [][]byte{{}}
+	}
+	scans := make([]*scan, len(prefixes))
+	for i, prefix := range prefixes {
+		scans[i] = newScan(tx.Bucket(bucket).Cursor(), prefix, f.Since, f.Until)
+	}
+	return scans
+}
+
+// scan iterates over the index keys with a prefix, newest first, within a
+// created_at range.
+type scan struct {
+	c      *bolt.Cursor
+	prefix []byte
+	stop   []byte
+	key    []byte
+}
+
+// newScan positions a scan on the newest key created at or before until and
+// stops it after the oldest key created at or after since.
+func newScan(c *bolt.Cursor, prefix []byte, since int, until int) *scan {
+	sc := &scan{c: c, prefix: prefix}
+	start := prefix
+	if until != 0 {
+		start = join(prefix, timestamp(until))
+	}
+	if since != 0 {
+		sc.stop = join(timestamp(since), bytes.Repeat([]byte{0xff}, idSize))
+	}
+	sc.key, _ = c.Seek(start)
+	sc.check()
+	return sc
+}
+
+// advance moves the scan to the next key.
+func (sc *scan) advance() {
+	sc.key, _ = sc.c.Next()
+	sc.check()
+}
+
+// check ends the scan when the key leaves the prefix or the range.
+func (sc *scan) check() {
+	if sc.key == nil {
+		return
+	}
+	if len(sc.key) != len(sc.prefix)+keySize || !bytes.HasPrefix(sc.key, sc.prefix) {
+		sc.key = nil
+		return
+	}
+	if sc.stop != nil && bytes.Compare(sc.suffix(), sc.stop) > 0 {
+		sc.key = nil
+	}
+}
+
+// suffix returns the timestamp and ID part of the current key.
+func (sc *scan) suffix() []byte {
+	return sc.key[len(sc.key)-keySize:]
+}
+
+// decodeHex decodes a 32 byte hex value, naming it in the error.
+func decodeHex(v string, name string) ([]byte, error) {
+	b, err := hex.DecodeString(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if len(b) != idSize {
+		return nil, fmt.Errorf("invalid %s: expected %d bytes, got %d", name, idSize, len(b))
+	}
+	return b, nil
+}
+
+// exactHex decodes the values if they are all full 32 byte hex values, and
+// returns nil otherwise.
+func exactHex(values []string) [][]byte {
+	if len(values) == 0 {
+		return nil
+	}
+	decoded := make([][]byte, len(values))
+	for i, v := range values {
+		b, err := decodeHex(v, "value")
+		if err != nil {
+			return nil
+		}
+		decoded[i] = b
+	}
+	return decoded
+}
+
+// hash returns a short hash of a variable length value used in index keys.
+func hash(v string) []byte {
+	sum := sha256.Sum256([]byte(v))
+	return sum[:hashSize]
+}
+
+// join concatenates the parts into a new slice.
+func join(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// kind encodes an event kind.
+func kind(k int) []byte {
+	b := make([]byte, kindSize)
+	binary.BigEndian.PutUint32(b, uint32(k))
+	return b
+}
+
+// open opens the bolt database and creates the buckets.
+func open(path string) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketEvents, bucketCreatedAt, bucketAuthor, bucketKind, bucketAuthorKind, bucketTag, bucketAddress} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// tagPrefix returns the index key prefix of a single-letter tag value.
+func tagPrefix(name string, value string) []byte {
+	return join([]byte(name), hash(value))
+}
+
+// timestamp encodes a created_at timestamp inverted, so that newer events
+// sort first.
+func timestamp(ts int) []byte {
+	if ts < 0 {
+		ts = 0
+	}
+	b := make([]byte, tsSize)
+	binary.BigEndian.PutUint64(b, math.MaxUint64-uint64(ts))
+	return b
+}
@@ -0,0 +1,236 @@
+package boltstore_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/message"
+	"github.com/go-nostr/nostr/relay/store"
+	"github.com/go-nostr/nostr/relay/store/boltstore"
+	"github.com/go-nostr/nostr/relay/store/storetest"
+	"github.com/go-nostr/nostr/tag"
+)
+
+func newStore(tb testing.TB) *boltstore.Store {
+	s, err := boltstore.New(filepath.Join(tb.TempDir(), "events.db"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		return newStore(t)
+	})
+}
+
+func TestStore_Reopen(t *testing.T) {
+	ctx := context.TODO()
+	path := filepath.Join(t.TempDir(), "events.db")
+	s, err := boltstore.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evt := storetest.NewEvent(storetest.Alice, 1, 100, "hello", tag.Tag{"t", "nostr"})
+	if err := s.Save(ctx, evt); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = boltstore.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	got, err := s.Query(ctx, message.Filters{{Tags: map[string][]string{"t": {"nostr"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !reflect.DeepEqual(*got[0], *evt) {
+		t.Errorf("expected %+v, got %+v", evt, got)
+	}
+}
+
+func TestStore_Save(t *testing.T) {
+	tests := []struct {
+		name string
+		evt  *event.Event
+	}{
+		{
+			name: "SHOULD reject event with invalid id",
+			evt:  &event.Event{ID: "zz", PubKey: storetest.Alice},
+		},
+		{
+			name: "SHOULD reject event with invalid pubkey",
+			evt:  &event.Event{ID: storetest.Alice, PubKey: "abc"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStore(t)
+			err := s.Save(context.TODO(), tt.evt)
+			t.Logf("got %v", err)
+			if err == nil {
+				t.Errorf("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestStore_Compact(t *testing.T) {
+	ctx := context.TODO()
+	s := newStore(t)
+	events := seed(t, s, 2000)
+	for _, evt := range events[:1900] {
+		if err := s.Delete(ctx, evt.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before, err := s.Size()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Compact(ctx); err != nil {
+		t.Fatal(err)
+	}
+	after, err := s.Size()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %d bytes before and %d bytes after", before, after)
+	if after >= before {
+		t.Errorf("expected size below %d, got %d", before, after)
+	}
+	count, err := s.Count(ctx, message.Filters{{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 100 {
+		t.Errorf("expected %v, got %v", 100, count)
+	}
+}
+
+func TestStore_Compact_Failure(t *testing.T) {
+	ctx := context.TODO()
+	path := filepath.Join(t.TempDir(), "events.db")
+	s, err := boltstore.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	seed(t, s, 10)
+	// a non-empty directory at the path of the database makes the swap fail
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(path, "blocker"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	err = s.Compact(ctx)
+	t.Logf("got %v", err)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if _, err := os.Stat(path + ".compact"); !os.IsNotExist(err) {
+		t.Errorf("expected compacted file to be removed, got %v", err)
+	}
+	count, err := s.Count(ctx, message.Filters{{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 10 {
+		t.Errorf("expected %v, got %v", 10, count)
+	}
+}
+
+// seed saves n text notes spread over 10 authors and 100 hashtags.
+func seed(tb testing.TB, s store.Store, n int) []*event.Event {
+	events := make([]*event.Event, n)
+	for i := range events {
+		author := strings.Repeat(fmt.Sprintf("%x", i%10), 64)
+		kind := 1
+		if i%5 == 0 {
+			kind = 7
+		}
+		events[i] = storetest.NewEvent(author, kind, 1000+i, fmt.Sprintf("note %d", i), tag.Tag{"t", fmt.Sprintf("topic%d", i%100)})
+		if err := s.Save(context.TODO(), events[i]); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return events
+}
+
+func BenchmarkStore_Save(b *testing.B) {
+	s := newStore(b)
+	events := make([]*event.Event, b.N)
+	for i := range events {
+		events[i] = storetest.NewEvent(storetest.Alice, 1, i, fmt.Sprintf("note %d", i), tag.Tag{"t", "nostr"})
+	}
+	b.ResetTimer()
+	for _, evt := range events {
+		if err := s.Save(context.TODO(), evt); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStore_Replace(b *testing.B) {
+	s := newStore(b)
+	events := make([]*event.Event, b.N)
+	for i := range events {
+		events[i] = storetest.NewEvent(storetest.Alice, 0, i+1, fmt.Sprintf(`{"name":"%d"}`, i))
+	}
+	b.ResetTimer()
+	for _, evt := range events {
+		if err := s.Replace(context.TODO(), evt); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStore_Query(b *testing.B) {
+	s := newStore(b)
+	events := seed(b, s, 10000)
+	benchmarks := []struct {
+		name    string
+		filters message.Filters
+	}{
+		{name: "id", filters: message.Filters{{IDs: []string{events[5005].ID}}}},
+		{name: "author", filters: message.Filters{{Authors: []string{strings.Repeat("3", 64)}, Limit: 100}}},
+		{name: "author and kind", filters: message.Filters{{Authors: []string{strings.Repeat("3", 64)}, Kinds: []int{1}, Limit: 100}}},
+		{name: "kind", filters: message.Filters{{Kinds: []int{7}, Limit: 100}}},
+		{name: "tag", filters: message.Filters{{Tags: map[string][]string{"t": {"topic42"}}}}},
+		{name: "since and until", filters: message.Filters{{Since: 5000, Until: 5500}}},
+		{name: "recent", filters: message.Filters{{Limit: 500}}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := s.Query(context.TODO(), bm.filters); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkStore_Count(b *testing.B) {
+	s := newStore(b)
+	seed(b, s, 10000)
+	filters := message.Filters{{Kinds: []int{1}}}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Count(context.TODO(), filters); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
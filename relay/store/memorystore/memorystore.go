@@ -0,0 +1,296 @@
+package memorystore
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/message"
+	"github.com/go-nostr/nostr/relay/store"
+)
+
+// New creates a new empty in-memory Store.
+func New() *Store {
+	return &Store{
+		events:    make(map[string]*event.Event),
+		byAddress: make(map[string]set),
+		byAuthor:  make(map[string]set),
+		byKind:    make(map[int]set),
+		byTag:     make(map[string]set),
+	}
+}
+
+// Store is a thread-safe in-memory store.Store with indexes by id, author,
+// kind, tag and replaceable address. Events are lost when the process exits.
+type Store struct {
+	events    map[string]*event.Event
+	byAddress map[string]set
+	byAuthor  map[string]set
+	byKind    map[int]set
+	byTag     map[string]set
+	mu        sync.RWMutex
+}
+
+// set is a set of event IDs.
+type set map[string]struct{}
+
+// Save stores a new event.
+func (s *Store) Save(ctx context.Context, evt *event.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.events[evt.ID]; ok {
+		return store.ErrDuplicate
+	}
+	s.insert(evt)
+	return nil
+}
+
+// Query returns the events matching any of the filters, newest first. The
+// returned events are shared with the store and must not be modified.
+func (s *Store) Query(ctx context.Context, filters message.Filters) ([]*event.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seen := make(set)
+	events := make([]*event.Event, 0)
+	for _, f := range filters {
+		for _, evt := range s.query(f) {
+			if _, ok := seen[evt.ID]; ok {
+				continue
+			}
+			seen[evt.ID] = struct{}{}
+			events = append(events, evt)
+		}
+	}
+	store.Sort(events)
+	return events, nil
+}
+
+// Delete removes the event with the given ID.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	evt, ok := s.events[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	s.remove(evt)
+	return nil
+}
+
+// Count returns the number of events matching any of the filters. Limits are
+// ignored when counting.
+func (s *Store) Count(ctx context.Context, filters message.Filters) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seen := make(set)
+	for _, f := range filters {
+		if f == nil {
+			continue
+		}
+		for id := range s.candidates(f) {
+			if evt := s.events[id]; f.Matches(evt) {
+				seen[id] = struct{}{}
+			}
+		}
+	}
+	return len(seen), nil
+}
+
+// Replace stores the event and removes the older versions it replaces.
+func (s *Store) Replace(ctx context.Context, evt *event.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.events[evt.ID]; ok {
+		return store.ErrDuplicate
+	}
+	previous := s.byAddress[store.Address(evt)]
+	for id := range previous {
+		if store.Newer(s.events[id], evt) {
+			return store.ErrOutdated
+		}
+	}
+	for id := range previous {
+		s.remove(s.events[id])
+	}
+	s.insert(evt)
+	return nil
+}
+
+// candidates returns the IDs of the events that may match the filter, using
+// the most selective index available.
+func (s *Store) candidates(f *message.Filter) set {
+	if len(f.IDs) > 0 && allExact(f.IDs) {
+		ids := make(set, len(f.IDs))
+		for _, id := range f.IDs {
+			if _, ok := s.events[id]; ok {
+				ids[id] = struct{}{}
+			}
+		}
+		return ids
+	}
+	var best set
+	consider := func(ids set) {
+		if best == nil || len(ids) < len(best) {
+			best = ids
+		}
+	}
+	if len(f.Authors) > 0 && allExact(f.Authors) {
+		consider(union(s.byAuthor, f.Authors))
+	}
+	if len(f.Kinds) > 0 {
+		ids := make(set)
+		for _, k := range f.Kinds {
+			for id := range s.byKind[k] {
+				ids[id] = struct{}{}
+			}
+		}
+		consider(ids)
+	}
+	for name, values := range f.TagValues() {
+		keys := make([]string, len(values))
+		for i, v := range values {
+			keys[i] = tagKey(name, v)
+		}
+		consider(union(s.byTag, keys))
+	}
+	if best != nil {
+		return best
+	}
+	ids := make(set, len(s.events))
+	for id := range s.events {
+		ids[id] = struct{}{}
+	}
+	return ids
+}
+
+// insert adds the event to the store and its indexes.
+func (s *Store) insert(evt *event.Event) {
+	cp := *evt
+	s.events[cp.ID] = &cp
+	add(s.byAuthor, cp.PubKey, cp.ID)
+	if s.byKind[cp.Kind] == nil {
+		s.byKind[cp.Kind] = make(set)
+	}
+	s.byKind[cp.Kind][cp.ID] = struct{}{}
+	for _, key := range tagKeys(&cp) {
+		add(s.byTag, key, cp.ID)
+	}
+	if event.IsReplaceable(cp.Kind) || event.IsParameterizedReplaceable(cp.Kind) {
+		add(s.byAddress, store.Address(&cp), cp.ID)
+	}
+}
+
+// query returns the events matching a single filter, newest first and
+// truncated to the filter limit.
+func (s *Store) query(f *message.Filter) []*event.Event {
+	if f == nil {
+		return nil
+	}
+	events := make([]*event.Event, 0)
+	for id := range s.candidates(f) {
+		if evt := s.events[id]; f.Matches(evt) {
+			events = append(events, evt)
+		}
+	}
+	store.Sort(events)
+	if f.Limit > 0 && len(events) > f.Limit {
+		events = events[:f.Limit]
+	}
+	return events
+}
+
+// remove deletes the event from the store and its indexes.
+func (s *Store) remove(evt *event.Event) {
+	delete(s.events, evt.ID)
+	del(s.byAuthor, evt.PubKey, evt.ID)
+	if ids := s.byKind[evt.Kind]; ids != nil {
+		delete(ids, evt.ID)
+		if len(ids) == 0 {
+			delete(s.byKind, evt.Kind)
+		}
+	}
+	for _, key := range tagKeys(evt) {
+		del(s.byTag, key, evt.ID)
+	}
+	del(s.byAddress, store.Address(evt), evt.ID)
+}
+
+// add adds the ID to the set stored under the key.
+func add(index map[string]set, key string, id string) {
+	if index[key] == nil {
+		index[key] = make(set)
+	}
+	index[key][id] = struct{}{}
+}
+
+// allExact reports whether every value is a full 64 character hex value
+// rather than a prefix.
+func allExact(values []string) bool {
+	for _, v := range values {
+		if len(v) != 64 {
+			return false
+		}
+	}
+	return true
+}
+
+// del removes the ID from the set stored under the key.
+func del(index map[string]set, key string, id string) {
+	ids := index[key]
+	if ids == nil {
+		return
+	}
+	delete(ids, id)
+	if len(ids) == 0 {
+		delete(index, key)
+	}
+}
+
+// tagKey returns the index key of a single-letter tag value.
+func tagKey(name string, value string) string {
+	return name + ":" + value
+}
+
+// tagKeys returns the index keys of the single-letter tags of the event.
+func tagKeys(evt *event.Event) []string {
+	keys := make([]string, 0, len(evt.Tags))
+	for _, t := range evt.Tags {
+		if len(t) < 2 {
+			continue
+		}
+		name, ok := t[0].(string)
+		if !ok || len(name) != 1 {
+			continue
+		}
+		if value, ok := t[1].(string); ok {
+			keys = append(keys, tagKey(name, value))
+		}
+	}
+	return keys
+}
+
+// union returns the union of the sets stored under the keys.
+func union(index map[string]set, keys []string) set {
+	ids := make(set)
+	for _, key := range keys {
+		for id := range index[key] {
+			ids[id] = struct{}{}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,15 @@
+package memorystore_test
+
+import (
+	"testing"
+
+	"github.com/go-nostr/nostr/relay/store"
+	"github.com/go-nostr/nostr/relay/store/memorystore"
+	"github.com/go-nostr/nostr/relay/store/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		return memorystore.New()
+	})
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/message"
+)
+
+var (
+	// ErrDuplicate is returned when saving an event that is already stored.
+	ErrDuplicate = errors.New("event already stored")
+	// ErrNotFound is returned when deleting an event that is not stored.
+	ErrNotFound = errors.New("event not found")
+	// ErrOutdated is returned when replacing an event with an older version.
+	ErrOutdated = errors.New("newer version of event already stored")
+)
+
+// Store persists events and answers queries for a relay.
+type Store interface {
+	// Save stores a new event. It returns ErrDuplicate if the event is already
+	// stored.
+	Save(ctx context.Context, evt *event.Event) error
+	// Query returns the events matching any of the filters, newest first. The
+	// limit of each filter applies to the events matched by that filter.
+	Query(ctx context.Context, filters message.Filters) ([]*event.Event, error)
+	// Delete removes the event with the given ID. It returns ErrNotFound if
+	// the event is not stored.
+	Delete(ctx context.Context, id string) error
+	// Count returns the number of events matching any of the filters.
+	Count(ctx context.Context, filters message.Filters) (int, error)
+	// Replace stores a replaceable or parameterized replaceable event and
+	// removes the versions it replaces. It returns ErrOutdated if a newer
+	// version is already stored.
+	Replace(ctx context.Context, evt *event.Event) error
+}
+
+// Address returns the key identifying the versions of a replaceable or
+// parameterized replaceable event that replace each other.
+func Address(evt *event.Event) string {
+	if event.IsParameterizedReplaceable(evt.Kind) {
+		return evt.PubKey + ":" + strconv.Itoa(evt.Kind) + ":" + evt.Identifier()
+	}
+	return evt.PubKey + ":" + strconv.Itoa(evt.Kind)
+}
+
+// Newer reports whether a is a newer version than b. Versions created at the
+// same time are ordered by lowest ID, as required by NIP-01.
+func Newer(a *event.Event, b *event.Event) bool {
+	if a.CreatedAt != b.CreatedAt {
+		return a.CreatedAt > b.CreatedAt
+	}
+	return a.ID < b.ID
+}
+
+// Sort sorts events newest first.
+func Sort(events []*event.Event) {
+	sort.Slice(events, func(i, j int) bool {
+		return Newer(events[i], events[j])
+	})
+}
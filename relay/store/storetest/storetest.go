@@ -0,0 +1,291 @@
+// Package storetest provides a conformance test suite for store.Store
+// implementations.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/message"
+	"github.com/go-nostr/nostr/relay/store"
+	"github.com/go-nostr/nostr/tag"
+)
+
+// Authors used by the events of the suite.
+var (
+	Alice = strings.Repeat("a", 64)
+	Bob   = strings.Repeat("b", 64)
+)
+
+// NewEvent creates an event with a computed ID. Events are not signed because
+// stores do not verify signatures.
+func NewEvent(pubKey string, kind int, createdAt int, content string, tags ...tag.Tag) *event.Event {
+	evt := event.New(kind, content, tags...)
+	if evt.Tags == nil {
+		evt.Tags = []tag.Tag{}
+	}
+	evt.PubKey = pubKey
+	evt.CreatedAt = createdAt
+	evt.ID = evt.ComputeID()
+	return evt
+}
+
+// Run runs the conformance suite against stores created by newStore. Each
+// subtest gets a new, empty store.
+func Run(t *testing.T, newStore func(t *testing.T) store.Store) {
+	ctx := context.TODO()
+	note1 := NewEvent(Alice, 1, 100, "first", tag.Tag{"t", "nostr"})
+	note2 := NewEvent(Alice, 1, 200, "second", tag.Tag{"e", note1.ID})
+	note3 := NewEvent(Bob, 1, 300, "third", tag.Tag{"p", Alice}, tag.Tag{"t", "nostr"})
+	reaction := NewEvent(Bob, 7, 400, "+", tag.Tag{"e", note1.ID}, tag.Tag{"p", Alice})
+	all := []*event.Event{note1, note2, note3, reaction}
+	seed := func(t *testing.T) store.Store {
+		s := newStore(t)
+		for _, evt := range all {
+			if err := s.Save(ctx, evt); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return s
+	}
+
+	t.Run("SHOULD reject duplicate event", func(t *testing.T) {
+		s := seed(t)
+		if err := s.Save(ctx, note1); !errors.Is(err, store.ErrDuplicate) {
+			t.Errorf("expected %v, got %v", store.ErrDuplicate, err)
+		}
+	})
+
+	queries := []struct {
+		name    string
+		filters message.Filters
+		expect  []*event.Event
+	}{
+		{
+			name:    "SHOULD query all events newest first",
+			filters: message.Filters{{}},
+			expect:  []*event.Event{reaction, note3, note2, note1},
+		},
+		{
+			name:    "SHOULD query by id",
+			filters: message.Filters{{IDs: []string{note2.ID}}},
+			expect:  []*event.Event{note2},
+		},
+		{
+			name:    "SHOULD query by id prefix",
+			filters: message.Filters{{IDs: []string{note3.ID[:8]}}},
+			expect:  []*event.Event{note3},
+		},
+		{
+			name:    "SHOULD query by author",
+			filters: message.Filters{{Authors: []string{Bob}}},
+			expect:  []*event.Event{reaction, note3},
+		},
+		{
+			name:    "SHOULD query by author prefix",
+			filters: message.Filters{{Authors: []string{"aaaa"}}},
+			expect:  []*event.Event{note2, note1},
+		},
+		{
+			name:    "SHOULD query by kind",
+			filters: message.Filters{{Kinds: []int{7}}},
+			expect:  []*event.Event{reaction},
+		},
+		{
+			name:    "SHOULD query by tag",
+			filters: message.Filters{{Tags: map[string][]string{"e": {note1.ID}}}},
+			expect:  []*event.Event{reaction, note2},
+		},
+		{
+			name:    "SHOULD query by tag and deprecated event ids",
+			filters: message.Filters{{Tags: map[string][]string{"e": {"missing"}}, EventIDs: []string{note1.ID}}},
+			expect:  []*event.Event{reaction, note2},
+		},
+		{
+			name:    "SHOULD query by deprecated public keys",
+			filters: message.Filters{{PublicKeys: []string{Alice}}},
+			expect:  []*event.Event{reaction, note3},
+		},
+		{
+			name:    "SHOULD query by tag and kind",
+			filters: message.Filters{{Kinds: []int{1}, Tags: map[string][]string{"t": {"nostr"}}}},
+			expect:  []*event.Event{note3, note1},
+		},
+		{
+			name:    "SHOULD query by since and until",
+			filters: message.Filters{{Since: 200, Until: 300}},
+			expect:  []*event.Event{note3, note2},
+		},
+		{
+			name:    "SHOULD apply limit to newest events",
+			filters: message.Filters{{Limit: 2}},
+			expect:  []*event.Event{reaction, note3},
+		},
+		{
+			name:    "SHOULD merge filters without duplicates",
+			filters: message.Filters{{Authors: []string{Alice}}, {Kinds: []int{1}, Limit: 1}},
+			expect:  []*event.Event{note3, note2, note1},
+		},
+		{
+			name:    "SHOULD return nothing without match",
+			filters: message.Filters{{Kinds: []int{30023}}},
+			expect:  []*event.Event{},
+		},
+	}
+	for _, tt := range queries {
+		t.Run(tt.name, func(t *testing.T) {
+			s := seed(t)
+			got, err := s.Query(ctx, tt.filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ids(tt.expect), ids(got)) {
+				t.Errorf("expected %v, got %v", ids(tt.expect), ids(got))
+			}
+			if len(got) > 0 && !reflect.DeepEqual(*got[0], *tt.expect[0]) {
+				t.Errorf("expected %+v, got %+v", *tt.expect[0], *got[0])
+			}
+			count, err := s.Count(ctx, tt.filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expect := countable(tt.filters, all); count != expect {
+				t.Errorf("expected count %v, got %v", expect, count)
+			}
+		})
+	}
+
+	t.Run("SHOULD delete event", func(t *testing.T) {
+		s := seed(t)
+		if err := s.Delete(ctx, note2.ID); err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.Query(ctx, message.Filters{{Authors: []string{Alice}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect := ids([]*event.Event{note1}); !reflect.DeepEqual(expect, ids(got)) {
+			t.Errorf("expected %v, got %v", expect, ids(got))
+		}
+		got, err = s.Query(ctx, message.Filters{{Tags: map[string][]string{"e": {note1.ID}}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect := ids([]*event.Event{reaction}); !reflect.DeepEqual(expect, ids(got)) {
+			t.Errorf("expected %v, got %v", expect, ids(got))
+		}
+		if err := s.Delete(ctx, note2.ID); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("expected %v, got %v", store.ErrNotFound, err)
+		}
+	})
+
+	t.Run("SHOULD replace replaceable event", func(t *testing.T) {
+		s := newStore(t)
+		v1 := NewEvent(Alice, 0, 100, `{"name":"alice"}`)
+		v2 := NewEvent(Alice, 0, 200, `{"name":"Alice"}`)
+		other := NewEvent(Bob, 0, 50, `{"name":"bob"}`)
+		for _, evt := range []*event.Event{v1, other, v2} {
+			if err := s.Replace(ctx, evt); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.Replace(ctx, v1); !errors.Is(err, store.ErrOutdated) {
+			t.Errorf("expected %v, got %v", store.ErrOutdated, err)
+		}
+		if err := s.Replace(ctx, v2); !errors.Is(err, store.ErrDuplicate) {
+			t.Errorf("expected %v, got %v", store.ErrDuplicate, err)
+		}
+		got, err := s.Query(ctx, message.Filters{{Kinds: []int{0}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect := ids([]*event.Event{v2, other}); !reflect.DeepEqual(expect, ids(got)) {
+			t.Errorf("expected %v, got %v", expect, ids(got))
+		}
+	})
+
+	t.Run("SHOULD replace parameterized replaceable event by identifier", func(t *testing.T) {
+		s := newStore(t)
+		a1 := NewEvent(Alice, 30023, 100, "draft", tag.Tag{"d", "a"})
+		a2 := NewEvent(Alice, 30023, 200, "final", tag.Tag{"d", "a"})
+		b1 := NewEvent(Alice, 30023, 150, "other", tag.Tag{"d", "b"})
+		for _, evt := range []*event.Event{a1, b1, a2} {
+			if err := s.Replace(ctx, evt); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err := s.Query(ctx, message.Filters{{Authors: []string{Alice}, Kinds: []int{30023}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect := ids([]*event.Event{a2, b1}); !reflect.DeepEqual(expect, ids(got)) {
+			t.Errorf("expected %v, got %v", expect, ids(got))
+		}
+	})
+
+	t.Run("SHOULD keep lowest id for replaceable events created at the same time", func(t *testing.T) {
+		s := newStore(t)
+		x := NewEvent(Alice, 3, 100, "x")
+		y := NewEvent(Alice, 3, 100, "y")
+		low, high := x, y
+		if high.ID < low.ID {
+			low, high = high, low
+		}
+		if err := s.Replace(ctx, high); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Replace(ctx, low); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Replace(ctx, NewEvent(Alice, 3, 100, high.Content)); !errors.Is(err, store.ErrOutdated) && !errors.Is(err, store.ErrDuplicate) {
+			t.Errorf("expected %v, got %v", store.ErrOutdated, err)
+		}
+		got, err := s.Query(ctx, message.Filters{{Kinds: []int{3}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect := ids([]*event.Event{low}); !reflect.DeepEqual(expect, ids(got)) {
+			t.Errorf("expected %v, got %v", expect, ids(got))
+		}
+	})
+
+	t.Run("SHOULD return context error", func(t *testing.T) {
+		s := seed(t)
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err := s.Query(ctx, message.Filters{{}}); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v, got %v", context.Canceled, err)
+		}
+	})
+}
+
+// countable returns the number of events matching any of the filters,
+// ignoring limits.
+func countable(filters message.Filters, events []*event.Event) int {
+	n := 0
+	for _, evt := range events {
+		for _, f := range filters {
+			cp := *f
+			cp.Limit = 0
+			if cp.Matches(evt) {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
+
+// ids returns the IDs of the events.
+func ids(events []*event.Event) []string {
+	s := make([]string, len(events))
+	for i, evt := range events {
+		s[i] = fmt.Sprintf("%s(%s)", evt.ID[:8], evt.Content)
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+package delegationtag
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Conditions restricts the events a delegatee may publish on behalf of the
+// delegator. Zero values mean the field is not restricted.
+type Conditions struct {
+	Kinds         []int // Kinds lists the allowed event kinds.
+	CreatedAfter  int   // CreatedAfter requires created_at to be greater than the value.
+	CreatedBefore int   // CreatedBefore requires created_at to be less than the value.
+}
+
+// ParseConditions parses a query string of conditions such as
+// "kind=1&created_at>1674834236&created_at<1677426236".
+func ParseConditions(s string) (*Conditions, error) {
+	c := new(Conditions)
+	if s == "" {
+		return c, nil
+	}
+	for _, part := range strings.Split(s, "&") {
+		var (
+			field, value string
+			err          error
+		)
+		switch {
+		case strings.HasPrefix(part, "kind="):
+			field, value = "kind", strings.TrimPrefix(part, "kind=")
+			var kind int
+			kind, err = strconv.Atoi(value)
+			c.Kinds = append(c.Kinds, kind)
+		case strings.HasPrefix(part, "created_at>"):
+			field, value = "created_at>", strings.TrimPrefix(part, "created_at>")
+			c.CreatedAfter, err = strconv.Atoi(value)
+		case strings.HasPrefix(part, "created_at<"):
+			field, value = "created_at<", strings.TrimPrefix(part, "created_at<")
+			c.CreatedBefore, err = strconv.Atoi(value)
+		default:
+			return nil, fmt.Errorf("unsupported delegation condition %q", part)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid delegation condition %s%s", field, value)
+		}
+	}
+	return c, nil
+}
+
+// Check checks an event kind and creation time against the conditions.
+func (c *Conditions) Check(kind int, createdAt int) error {
+	if len(c.Kinds) > 0 {
+		allowed := false
+		for _, k := range c.Kinds {
+			if k == kind {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("kind %d is not delegated", kind)
+		}
+	}
+	if c.CreatedAfter != 0 && createdAt <= c.CreatedAfter {
+		return fmt.Errorf("created_at %d is not after %d", createdAt, c.CreatedAfter)
+	}
+	if c.CreatedBefore != 0 && createdAt >= c.CreatedBefore {
+		return fmt.Errorf("created_at %d is not before %d", createdAt, c.CreatedBefore)
+	}
+	return nil
+}
+
+// String returns the conditions as a query string.
+func (c *Conditions) String() string {
+	parts := make([]string, 0, len(c.Kinds)+2)
+	for _, k := range c.Kinds {
+		parts = append(parts, "kind="+strconv.Itoa(k))
+	}
+	if c.CreatedAfter != 0 {
+		parts = append(parts, "created_at>"+strconv.Itoa(c.CreatedAfter))
+	}
+	if c.CreatedBefore != 0 {
+		parts = append(parts, "created_at<"+strconv.Itoa(c.CreatedBefore))
+	}
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,126 @@
+package delegationtag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/keys"
+	"github.com/go-nostr/nostr/tag"
+)
+
+const Type = "delegation"
+
+// New tag containing the delegator public key, the conditions string and the
+// delegation token. For more information, visit:
+// https://github.com/nostr-protocol/nips/blob/master/26.md
+func New(delegatorPubKey string, conditions string, token string) tag.Tag {
+	return tag.New(Type, delegatorPubKey, conditions, token)
+}
+
+// Create signs a delegation token with the delegator private key and returns
+// the delegation tag to be added to events of the delegatee.
+func Create(delegatorPrvKeyHex string, delegateePubKey string, conditions string) (tag.Tag, error) {
+	if _, err := ParseConditions(conditions); err != nil {
+		return nil, err
+	}
+	prvKey, err := keys.ParsePrivateKey(delegatorPrvKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	defer prvKey.Zero()
+	hash := tokenHash(delegateePubKey, conditions)
+	sig, err := prvKey.Sign(hash[:])
+	if err != nil {
+		return nil, err
+	}
+	return New(prvKey.PublicKey().Hex(), conditions, hex.EncodeToString(sig)), nil
+}
+
+// Delegation is a parsed delegation tag. Conditions is kept in its original
+// form because it is part of the signed delegation string.
+type Delegation struct {
+	DelegatorPubKey string
+	Conditions      string
+	Token           string
+}
+
+// Parse parses a delegation tag.
+func Parse(t tag.Tag) (*Delegation, error) {
+	if len(t) < 4 || t[0] != Type {
+		return nil, fmt.Errorf("invalid delegation tag")
+	}
+	values := make([]string, 3)
+	for i := range values {
+		str, ok := t[i+1].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid delegation tag")
+		}
+		values[i] = str
+	}
+	return &Delegation{
+		DelegatorPubKey: values[0],
+		Conditions:      values[1],
+		Token:           values[2],
+	}, nil
+}
+
+// Check checks the delegation token signature against the delegatee public
+// key and the conditions against the kind and creation time of an event.
+func (d *Delegation) Check(evt *event.Event) error {
+	conditions, err := ParseConditions(d.Conditions)
+	if err != nil {
+		return err
+	}
+	pubKeyStr, err := hex.DecodeString(d.DelegatorPubKey)
+	if err != nil {
+		return fmt.Errorf("invalid delegator public key: %w", err)
+	}
+	pubKey, err := schnorr.ParsePubKey(pubKeyStr)
+	if err != nil {
+		return fmt.Errorf("invalid delegator public key: %w", err)
+	}
+	sigStr, err := hex.DecodeString(d.Token)
+	if err != nil {
+		return fmt.Errorf("invalid delegation token: %w", err)
+	}
+	sig, err := schnorr.ParseSignature(sigStr)
+	if err != nil {
+		return fmt.Errorf("invalid delegation token: %w", err)
+	}
+	hash := tokenHash(evt.PubKey, d.Conditions)
+	if !sig.Verify(hash[:], pubKey) {
+		return fmt.Errorf("invalid delegation token: unable to verify")
+	}
+	return conditions.Check(evt.Kind, evt.CreatedAt)
+}
+
+// Verify verifies the event and its delegation tag, if any, and returns the
+// effective author of the event: the delegator public key for delegated
+// events, otherwise the event public key.
+func Verify(evt *event.Event) (string, error) {
+	if err := evt.Verify(); err != nil {
+		return "", err
+	}
+	for _, t := range evt.Tags {
+		if len(t) == 0 || t[0] != Type {
+			continue
+		}
+		d, err := Parse(t)
+		if err != nil {
+			return "", err
+		}
+		if err := d.Check(evt); err != nil {
+			return "", err
+		}
+		return d.DelegatorPubKey, nil
+	}
+	return evt.PubKey, nil
+}
+
+// tokenHash returns the hash of the delegation string signed by the delegator.
+func tokenHash(delegateePubKey string, conditions string) [32]byte {
+	return sha256.Sum256([]byte("nostr:delegation:" + delegateePubKey + ":" + conditions))
+}
@@ -0,0 +1,270 @@
+package delegationtag_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-nostr/nostr/event"
+	"github.com/go-nostr/nostr/tag"
+	"github.com/go-nostr/nostr/tag/delegationtag"
+)
+
+const (
+	delegatorPrvKey = "ee35e8bb71131c02c1d7e73231daa48e9953d329a4b701f7133c8f46dd21139c"
+	delegatorPubKey = "8e0d3d3eb2881ec137a11debe736a9086715a8c8beeeda615780064d68bc25dd"
+	delegateePrvKey = "777e4f60b4aa87937e13acc84f7abcc3c93cc035cb4c1e9f7a9086dd78fffce1"
+	delegateePubKey = "477318cfb5427b9cfc66a9fa376150c1ddbc62115ae27cef72417eb959691396"
+	conditions      = "kind=1&created_at>1674834236&created_at<1677426236"
+)
+
+// delegatedEvent is signed by an independent implementation using the keys of
+// the NIP-26 examples.
+const delegatedEvent = `{"id":"61bdd6961b25946bee08769acf2afe017fce119e6aedda4b04a2d42677f3c682","pubkey":"477318cfb5427b9cfc66a9fa376150c1ddbc62115ae27cef72417eb959691396","created_at":1677426200,"kind":1,"tags":[["delegation","8e0d3d3eb2881ec137a11debe736a9086715a8c8beeeda615780064d68bc25dd","kind=1&created_at>1674834236&created_at<1677426236","a77aee4fa16c1a2283548a2f2a6e67ea5dde242c79fbd88ec42167707ce75d34157267742348cd8ed65621a5f53ff560383465401fc164dc93d957fd47ade730"]],"content":"Hello, world!","sig":"8d88dd007904252f2f54b60b8f934924b93c78528c139f0eadd470b6f3e98dfc114ad8c6200da89fe0ed4e490ceda78d1f80a3250c84f21351c8b53b191eab6c"}`
+
+func Test_New(t *testing.T) {
+	got := delegationtag.New(delegatorPubKey, conditions, "token")
+	expect := tag.Tag{"delegation", delegatorPubKey, conditions, "token"}
+	if !reflect.DeepEqual(expect, got) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+	t.Logf("got %v", got)
+}
+
+func Test_ParseConditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		expect  *delegationtag.Conditions
+		wantErr bool
+	}{
+		{
+			name: "SHOULD parse kind and created_at conditions",
+			s:    conditions,
+			expect: &delegationtag.Conditions{
+				Kinds:         []int{1},
+				CreatedAfter:  1674834236,
+				CreatedBefore: 1677426236,
+			},
+		},
+		{
+			name: "SHOULD parse multiple kinds",
+			s:    "kind=0&kind=1",
+			expect: &delegationtag.Conditions{
+				Kinds: []int{0, 1},
+			},
+		},
+		{
+			name:    "SHOULD reject unknown condition",
+			s:       "kind=1&pubkey=abc",
+			wantErr: true,
+		},
+		{
+			name:    "SHOULD reject malformed value",
+			s:       "created_at>yesterday",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := delegationtag.ParseConditions(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(tt.expect, got) {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+			if !tt.wantErr && got.String() != tt.s {
+				t.Errorf("expected %v, got %v", tt.s, got.String())
+			}
+			t.Logf("got %v", got)
+		})
+	}
+}
+
+func TestConditions_Check(t *testing.T) {
+	c, _ := delegationtag.ParseConditions(conditions)
+	tests := []struct {
+		name      string
+		kind      int
+		createdAt int
+		wantErr   bool
+	}{
+		{
+			name:      "SHOULD allow matching kind within window",
+			kind:      1,
+			createdAt: 1677426200,
+		},
+		{
+			name:      "SHOULD reject other kind",
+			kind:      0,
+			createdAt: 1677426200,
+			wantErr:   true,
+		},
+		{
+			name:      "SHOULD reject created_at after window",
+			kind:      1,
+			createdAt: 1677426298,
+			wantErr:   true,
+		},
+		{
+			name:      "SHOULD reject created_at before window",
+			kind:      1,
+			createdAt: 1674834236,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.Check(tt.kind, tt.createdAt)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+			t.Logf("got %v", err)
+		})
+	}
+}
+
+func Test_Create(t *testing.T) {
+	tests := []struct {
+		name       string
+		prvKey     string
+		conditions string
+		expect     string
+		wantErr    bool
+	}{
+		{
+			name:       "SHOULD create delegation tag of delegator",
+			prvKey:     delegatorPrvKey,
+			conditions: conditions,
+			expect:     delegatorPubKey,
+		},
+		{
+			name:       "SHOULD reject zero private key",
+			prvKey:     "0000000000000000000000000000000000000000000000000000000000000000",
+			conditions: conditions,
+			wantErr:    true,
+		},
+		{
+			name:       "SHOULD reject malformed private key",
+			prvKey:     "ee35e8bb",
+			conditions: conditions,
+			wantErr:    true,
+		},
+		{
+			name:       "SHOULD reject invalid conditions",
+			prvKey:     delegatorPrvKey,
+			conditions: "kind=1&pubkey=abc",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := delegationtag.Create(tt.prvKey, delegateePubKey, tt.conditions)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			t.Logf("got %v", got)
+			if tt.wantErr {
+				return
+			}
+			if len(got) != 4 || got[1] != tt.expect || got[2] != tt.conditions {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func Test_Verify(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   func(t *testing.T) *event.Event
+		expect  string
+		wantErr bool
+	}{
+		{
+			name: "SHOULD report delegator as author of cross-implementation event",
+			event: func(t *testing.T) *event.Event {
+				evt := new(event.Event)
+				if err := evt.Unmarshal([]byte(delegatedEvent)); err != nil {
+					t.Fatal(err)
+				}
+				return evt
+			},
+			expect: delegatorPubKey,
+		},
+		{
+			name: "SHOULD report delegator as author of created delegation",
+			event: func(t *testing.T) *event.Event {
+				dt, err := delegationtag.Create(delegatorPrvKey, delegateePubKey, conditions)
+				if err != nil {
+					t.Fatal(err)
+				}
+				evt := event.New(1, "Hello, world!", dt)
+				evt.CreatedAt = 1677426200
+				sign(t, evt)
+				return evt
+			},
+			expect: delegatorPubKey,
+		},
+		{
+			name: "SHOULD report pubkey as author without delegation",
+			event: func(t *testing.T) *event.Event {
+				evt := event.New(1, "Hello, world!")
+				sign(t, evt)
+				return evt
+			},
+			expect: delegateePubKey,
+		},
+		{
+			name: "SHOULD reject event outside of conditions",
+			event: func(t *testing.T) *event.Event {
+				dt, err := delegationtag.Create(delegatorPrvKey, delegateePubKey, conditions)
+				if err != nil {
+					t.Fatal(err)
+				}
+				evt := event.New(1, "Hello, world!", dt)
+				evt.CreatedAt = 1677426298
+				sign(t, evt)
+				return evt
+			},
+			wantErr: true,
+		},
+		{
+			name: "SHOULD reject token for a different delegatee",
+			event: func(t *testing.T) *event.Event {
+				dt, err := delegationtag.Create(delegatorPrvKey, delegatorPubKey, conditions)
+				if err != nil {
+					t.Fatal(err)
+				}
+				evt := event.New(1, "Hello, world!", dt)
+				evt.CreatedAt = 1677426200
+				sign(t, evt)
+				return evt
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := delegationtag.Verify(tt.event(t))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.expect {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+			t.Logf("got %v", got)
+		})
+	}
+}
+
+func sign(t *testing.T, evt *event.Event) {
+	signer, err := event.NewKeySigner(delegateePrvKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := evt.SignWith(context.TODO(), signer); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1 +1,33 @@
 package noncetag
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/go-nostr/nostr/tag"
+)
+
+const Type = "nonce"
+
+// New tag containing the proof-of-work nonce and the committed target
+// difficulty. For more information, visit:
+// https://github.com/nostr-protocol/nips/blob/master/13.md
+func New(nonce uint64, target int) tag.Tag {
+	return tag.New(Type, strconv.FormatUint(nonce, 10), strconv.Itoa(target))
+}
+
+// Target returns the target difficulty committed to by a nonce tag.
+func Target(t tag.Tag) (int, error) {
+	if len(t) < 3 || t[0] != Type {
+		return 0, fmt.Errorf("missing target difficulty")
+	}
+	str, ok := t[2].(string)
+	if !ok {
+		return 0, fmt.Errorf("invalid target difficulty")
+	}
+	target, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid target difficulty: %w", err)
+	}
+	return target, nil
+}
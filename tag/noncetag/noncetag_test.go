@@ -0,0 +1,77 @@
+package noncetag_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-nostr/nostr/tag"
+	"github.com/go-nostr/nostr/tag/noncetag"
+)
+
+func Test_New(t *testing.T) {
+	type args struct {
+		nonce  uint64
+		target int
+	}
+	tests := []struct {
+		name   string
+		args   args
+		expect tag.Tag
+	}{
+		{
+			name: "SHOULD construct nonce tag with nonce and target",
+			args: args{
+				nonce:  776797,
+				target: 20,
+			},
+			expect: tag.Tag{"nonce", "776797", "20"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := noncetag.New(tt.args.nonce, tt.args.target)
+			if !reflect.DeepEqual(tt.expect, got) {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+				return
+			}
+			t.Logf("got %v", got)
+		})
+	}
+}
+
+func Test_Target(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     tag.Tag
+		expect  int
+		wantErr bool
+	}{
+		{
+			name:   "SHOULD get committed target",
+			tag:    tag.Tag{"nonce", "776797", "20"},
+			expect: 20,
+		},
+		{
+			name:    "SHOULD fail without committed target",
+			tag:     tag.Tag{"nonce", "776797"},
+			wantErr: true,
+		},
+		{
+			name:    "SHOULD fail with malformed target",
+			tag:     tag.Tag{"nonce", "776797", "twenty"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := noncetag.Target(tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.expect {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+			t.Logf("got %v", got)
+		})
+	}
+}
@@ -1 +1,10 @@
 package relaytag
+
+import "github.com/go-nostr/nostr/tag"
+
+const Type = "relay"
+
+// New creates a new "relay" tag with the URL of a relay.
+func New(relayURL string) tag.Tag {
+	return tag.New(Type, relayURL)
+}